@@ -0,0 +1,159 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"encoding"
+	"reflect"
+	"sync"
+)
+
+var parsers = struct {
+	sync.RWMutex
+	byType map[reflect.Type]func(string) (any, error)
+}{
+	byType: map[reflect.Type]func(string) (any, error){},
+}
+
+// RegisterParser registers parse as the function used to parse values of
+// environment variables for fields of type T, or pointer to T. This makes
+// it possible to support types which cannot implement Decoder, for example,
+// those from third-party packages:
+//
+//	envs.RegisterParser(func(s string) (vendor.Level, error) {
+//		return vendor.ParseLevel(s)
+//	})
+//
+// Registered parsers take precedence over Decoder, encoding.TextUnmarshaler,
+// and the types supported by this package. Registering a parser for a type
+// which already has one replaces it.
+//
+// RegisterParser is safe for concurrent use, but is typically called from
+// an init function.
+func RegisterParser[T any](parse func(string) (T, error)) {
+	parsers.Lock()
+	defer parsers.Unlock()
+
+	parsers.byType[reflect.TypeOf((*T)(nil)).Elem()] = func(s string) (any, error) {
+		return parse(s)
+	}
+}
+
+// parserFor returns the registered parser for rt, or nil when there is
+// none.
+func parserFor(rt reflect.Type) func(string) (any, error) {
+	parsers.RLock()
+	defer parsers.RUnlock()
+
+	return parsers.byType[rt]
+}
+
+// Decoder is implemented by types which decode the value of an environment
+// variable themselves. The value is trimmed of surrounding spaces and
+// quotes before it is passed to DecodeEnv.
+//
+// Decoder takes precedence over encoding.TextUnmarshaler.
+type Decoder interface {
+	DecodeEnv(value string) error
+}
+
+// decoderFor returns a function decoding a string into v when v, or
+// what it points to, implements Decoder or encoding.TextUnmarshaler.
+func decoderFor(v any) func(string) error {
+	switch d := v.(type) {
+	case Decoder:
+		return d.DecodeEnv
+	case encoding.TextUnmarshaler:
+		return func(s string) error {
+			return d.UnmarshalText([]byte(s))
+		}
+	}
+
+	return nil
+}
+
+// handleParser sets value using s when a parser was registered for the type
+// of value, or the type it points to. It returns false when there is no
+// registered parser.
+func handleParser(name string, value reflect.Value, s *string) (bool, error) {
+	rt := value.Type()
+
+	parse := parserFor(rt)
+	isPointer := false
+	if parse == nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+		parse = parserFor(rt)
+		isPointer = true
+	}
+
+	if parse == nil {
+		return false, nil
+	}
+
+	if s == nil {
+		value.Set(reflect.Zero(value.Type()))
+		return true, nil
+	}
+
+	v, err := unquote(name, *s)
+	if err != nil {
+		return true, err
+	}
+
+	res, err := parse(v)
+	if err != nil {
+		return true, &ErrSyntax{EnvVar: name, Reason: err.Error(), Err: err}
+	}
+
+	p := reflect.New(rt)
+	if res != nil {
+		p.Elem().Set(reflect.ValueOf(res))
+	}
+
+	if isPointer {
+		value.Set(p)
+	} else {
+		value.Set(p.Elem())
+	}
+	return true, nil
+}
+
+// handleDecoder sets value using s when value, or its address, implements
+// Decoder or encoding.TextUnmarshaler. When value is a pointer, a new value
+// is allocated. It returns false when none of the interfaces is implemented.
+func handleDecoder(name string, value reflect.Value, s *string) (bool, error) {
+	target := value
+	if value.Kind() == reflect.Pointer {
+		target = reflect.New(value.Type().Elem())
+	} else if value.CanAddr() {
+		target = value.Addr()
+	}
+
+	if !target.CanInterface() {
+		return false, nil
+	}
+
+	decode := decoderFor(target.Interface())
+	if decode == nil {
+		return false, nil
+	}
+
+	if s == nil {
+		value.Set(reflect.Zero(value.Type()))
+		return true, nil
+	}
+
+	v, err := unquote(name, *s)
+	if err != nil {
+		return true, err
+	}
+
+	if err := decode(v); err != nil {
+		return true, &ErrSyntax{EnvVar: name, Reason: err.Error(), Err: err}
+	}
+
+	if value.Kind() == reflect.Pointer {
+		value.Set(target)
+	}
+	return true, nil
+}
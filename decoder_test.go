@@ -0,0 +1,202 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golistic/xgo/xt"
+)
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "debug":
+		*l = 1
+	case "info":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+type testRegion struct {
+	Name    string
+	Decoded bool
+}
+
+func (r *testRegion) DecodeEnv(value string) error {
+	r.Name = value
+	r.Decoded = true
+	return nil
+}
+
+// UnmarshalText is not used because Decoder takes precedence.
+func (r *testRegion) UnmarshalText([]byte) error {
+	return errors.New("should not be called")
+}
+
+// testVendorLevel mimics a type of a third-party package for which we
+// cannot implement Decoder.
+type testVendorLevel uint8
+
+type testVendorColor string
+
+func TestRegisterParser(t *testing.T) {
+	RegisterParser(func(s string) (testVendorLevel, error) {
+		switch s {
+		case "low":
+			return 1, nil
+		case "high":
+			return 9, nil
+		}
+		return 0, fmt.Errorf("unknown vendor level %q", s)
+	})
+
+	t.Run("value and pointer", func(t *testing.T) {
+		env := struct {
+			Level    testVendorLevel   `envVar:"VENDOR_LEVEL_k3dk8"`
+			PtrLevel *testVendorLevel  `envVar:"PTR_VENDOR_LEVEL_k3dk8"`
+			Naked    *testVendorLevel  `envVar:"NAKED_VENDOR_LEVEL_k3dk8"`
+			Levels   []testVendorLevel `envVar:"VENDOR_LEVELS_k3dk8"`
+		}{}
+
+		xt.OK(t, os.Setenv("VENDOR_LEVEL_k3dk8", "low"))
+		xt.OK(t, os.Setenv("PTR_VENDOR_LEVEL_k3dk8", "high"))
+		xt.OK(t, os.Setenv("VENDOR_LEVELS_k3dk8", "high,low"))
+		xt.OK(t, OSEnviron(&env))
+
+		xt.Eq(t, testVendorLevel(1), env.Level)
+		xt.Eq(t, testVendorLevel(9), *env.PtrLevel)
+		xt.Eq(t, nil, env.Naked)
+		xt.Eq(t, []testVendorLevel{9, 1}, env.Levels)
+
+		for _, k := range []string{"VENDOR_LEVEL_k3dk8", "PTR_VENDOR_LEVEL_k3dk8", "VENDOR_LEVELS_k3dk8"} {
+			xt.OK(t, os.Unsetenv(k))
+		}
+	})
+
+	t.Run("takes precedence over built-in types", func(t *testing.T) {
+		RegisterParser(func(s string) (time.Duration, error) {
+			return time.ParseDuration(s + "s")
+		})
+		defer func() {
+			parsers.Lock()
+			delete(parsers.byType, reflect.TypeOf(time.Duration(0)))
+			parsers.Unlock()
+		}()
+
+		env := struct {
+			Timeout time.Duration `envVar:"TIMEOUT_k3dk8"`
+		}{}
+		xt.OK(t, os.Setenv("TIMEOUT_k3dk8", "30"))
+		xt.OK(t, OSEnviron(&env))
+		xt.Eq(t, 30*time.Second, env.Timeout)
+		xt.OK(t, os.Unsetenv("TIMEOUT_k3dk8"))
+	})
+
+	t.Run("syntax: error returned by parser", func(t *testing.T) {
+		env := struct {
+			Level testVendorLevel `envVar:"VENDOR_LEVEL_x93kd"`
+		}{}
+
+		xt.OK(t, os.Setenv("VENDOR_LEVEL_x93kd", "medium"))
+		err := OSEnviron(&env)
+		xt.KO(t, err)
+		xt.Eq(t, `VENDOR_LEVEL_x93kd: syntax error (unknown vendor level "medium")`, err.Error())
+		xt.OK(t, os.Unsetenv("VENDOR_LEVEL_x93kd"))
+	})
+
+	t.Run("concurrent registration", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				RegisterParser(func(s string) (testVendorColor, error) {
+					return testVendorColor(strings.ToUpper(s)), nil
+				})
+				_ = parserFor(reflect.TypeOf(testVendorLevel(0)))
+			}()
+		}
+		wg.Wait()
+
+		env := struct {
+			Color testVendorColor `envVar:"VENDOR_COLOR_k3dk8"`
+		}{}
+		xt.OK(t, os.Setenv("VENDOR_COLOR_k3dk8", "red"))
+		xt.OK(t, OSEnviron(&env))
+		xt.Eq(t, testVendorColor("RED"), env.Color)
+		xt.OK(t, os.Unsetenv("VENDOR_COLOR_k3dk8"))
+	})
+}
+
+func TestDecoder(t *testing.T) {
+	t.Run("encoding.TextUnmarshaler", func(t *testing.T) {
+		env := struct {
+			Level    testLevel   `envVar:"LEVEL_d9ek3"`
+			PtrLevel *testLevel  `envVar:"PTR_LEVEL_d9ek3"`
+			Naked    *testLevel  `envVar:"NAKED_LEVEL_d9ek3"`
+			Default  testLevel   `envVar:"DEFAULT_LEVEL_d9ek3" default:"info"`
+			Levels   []testLevel `envVar:"LEVELS_d9ek3"`
+		}{}
+
+		xt.OK(t, os.Setenv("LEVEL_d9ek3", "debug"))
+		xt.OK(t, os.Setenv("PTR_LEVEL_d9ek3", `"INFO"`))
+		xt.OK(t, os.Setenv("LEVELS_d9ek3", "info,debug"))
+		xt.OK(t, OSEnviron(&env))
+
+		xt.Eq(t, testLevel(1), env.Level)
+		xt.Eq(t, testLevel(2), *env.PtrLevel)
+		xt.Eq(t, nil, env.Naked)
+		xt.Eq(t, testLevel(2), env.Default)
+		xt.Eq(t, []testLevel{2, 1}, env.Levels)
+
+		for _, k := range []string{"LEVEL_d9ek3", "PTR_LEVEL_d9ek3", "LEVELS_d9ek3"} {
+			xt.OK(t, os.Unsetenv(k))
+		}
+	})
+
+	t.Run("Decoder takes precedence", func(t *testing.T) {
+		env := struct {
+			Region    testRegion  `envVar:"REGION_d9ek3"`
+			PtrRegion *testRegion `envVar:"PTR_REGION_d9ek3"`
+		}{}
+
+		xt.OK(t, os.Setenv("REGION_d9ek3", "eu-west"))
+		xt.OK(t, os.Setenv("PTR_REGION_d9ek3", "us-east"))
+		xt.OK(t, OSEnviron(&env))
+
+		xt.Eq(t, "eu-west", env.Region.Name)
+		xt.Assert(t, env.Region.Decoded)
+		xt.Eq(t, "us-east", env.PtrRegion.Name)
+
+		xt.OK(t, os.Unsetenv("REGION_d9ek3"))
+		xt.OK(t, os.Unsetenv("PTR_REGION_d9ek3"))
+	})
+
+	t.Run("syntax: error returned by decoder", func(t *testing.T) {
+		env := struct {
+			Level testLevel `envVar:"LEVEL_x8dk2"`
+		}{}
+
+		xt.OK(t, os.Setenv("LEVEL_x8dk2", "verbose"))
+		err := OSEnviron(&env)
+		xt.KO(t, err)
+		xt.Eq(t, `LEVEL_x8dk2: syntax error (unknown level "verbose")`, err.Error())
+
+		var errSyntax *ErrSyntax
+		xt.Assert(t, errors.As(err, &errSyntax))
+		xt.KO(t, errors.Unwrap(err))
+		xt.OK(t, os.Unsetenv("LEVEL_x8dk2"))
+	})
+}
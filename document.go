@@ -0,0 +1,325 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// DotEnvDocument is a dot-env file which can be modified while keeping its
+// comments, blank lines, order, and the quoting of values. When not modified,
+// it is written back byte-for-byte identical to what was read.
+//
+// Modifications are applied to the text of the document, which is parsed again
+// using the rules of its dialect. References to variables within values, like
+// `$VAR`, are not updated when a variable is set, deleted, or renamed.
+type DotEnvDocument struct {
+	src        string
+	defs       []dotEnvDef
+	env        *Env
+	newScanner func() *dotEnvScanner
+	options    []Option
+}
+
+// ParseNodeJSDotEnvDocument reads a dot-env file from r as document according to
+// the same rules as NodeJSDotEnv().
+func ParseNodeJSDotEnvDocument(r io.Reader, options ...Option) (*DotEnvDocument, error) {
+	return parseDotEnvDocument(newNodeJSScanner, r, options)
+}
+
+// ParseNodeJSDotEnvDocumentFromFile reads the dot-env file with path as document.
+// See ParseNodeJSDotEnvDocument() for further details.
+func ParseNodeJSDotEnvDocumentFromFile(path string, options ...Option) (*DotEnvDocument, error) {
+	return readDotEnvDocument(newNodeJSScanner, path, options)
+}
+
+// ParseDjangoDotEnvDocument reads a dot-env file from r as document according to
+// the same rules as DjangoDotEnv().
+func ParseDjangoDotEnvDocument(r io.Reader, options ...Option) (*DotEnvDocument, error) {
+	return parseDotEnvDocument(newDjangoScanner, r, options)
+}
+
+// ParseDjangoDotEnvDocumentFromFile reads the dot-env file with path as document.
+// See ParseDjangoDotEnvDocument() for further details.
+func ParseDjangoDotEnvDocumentFromFile(path string, options ...Option) (*DotEnvDocument, error) {
+	return readDotEnvDocument(newDjangoScanner, path, options)
+}
+
+// ParseDockerComposeDotEnvDocument reads a dot-env file from r as document
+// according to the same rules as DockerComposeDotEnv().
+func ParseDockerComposeDotEnvDocument(r io.Reader, options ...Option) (*DotEnvDocument, error) {
+	return parseDotEnvDocument(newDockerComposeScanner, r, options)
+}
+
+// ParseDockerComposeDotEnvDocumentFromFile reads the dot-env file with path as
+// document. See ParseDockerComposeDotEnvDocument() for further details.
+func ParseDockerComposeDotEnvDocumentFromFile(path string, options ...Option) (*DotEnvDocument, error) {
+	return readDotEnvDocument(newDockerComposeScanner, path, options)
+}
+
+func parseDotEnvDocument(newScanner func() *dotEnvScanner, r io.Reader, options []Option) (*DotEnvDocument, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &DotEnvDocument{
+		newScanner: newScanner,
+		options:    options,
+	}
+
+	if err := doc.parse(string(src)); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func readDotEnvDocument(newScanner func() *dotEnvScanner, path string, options []Option) (*DotEnvDocument, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, &ErrReadingFile{FilePath: path, Err: err}
+	}
+	defer func() { _ = f.Close() }()
+
+	doc, err := parseDotEnvDocument(newScanner, f, options)
+	if e, ok := err.(*ErrSyntax); ok {
+		e.FilePath = path
+	}
+	return doc, err
+}
+
+// parse parses src and, when successful, makes it the text of doc.
+func (doc *DotEnvDocument) parse(src string) error {
+	s := doc.newScanner()
+	s.configure(doc.options)
+	if err := s.parse(strings.NewReader(src)); err != nil {
+		return err
+	}
+
+	env, err := s.env()
+	if err != nil {
+		return err
+	}
+
+	doc.src = src
+	doc.defs = s.defs
+	doc.env = env
+	return nil
+}
+
+// Len returns the number of variables in doc.
+func (doc *DotEnvDocument) Len() int {
+	return doc.env.Len()
+}
+
+// Names returns the names of the variables in the order they were defined.
+func (doc *DotEnvDocument) Names() []string {
+	return doc.env.Names()
+}
+
+// Vars returns the variables in the order they were defined.
+func (doc *DotEnvDocument) Vars() []EnvVar {
+	return doc.env.Vars()
+}
+
+// Get returns the variable with name and whether it is available. When the
+// variable is defined multiple times, the last definition is returned.
+func (doc *DotEnvDocument) Get(name string) (EnvVar, bool) {
+	return doc.env.Get(name)
+}
+
+// Lookup returns the value of the variable with name and whether it is
+// available. Like os.LookupEnv, naked variables are considered as not
+// available.
+func (doc *DotEnvDocument) Lookup(name string) (string, bool) {
+	return doc.env.Lookup(name)
+}
+
+// Set sets the variable with name to value. When the variable is defined,
+// the value of its last definition is replaced, keeping its quotes when
+// possible. Otherwise, the variable is added at the end of the document.
+// Values are quoted when needed, but escapes are never used.
+//
+// Returns ErrSyntax when name is not a valid variable name, or when value
+// cannot be written without escapes, for example, when it contains a newline.
+func (doc *DotEnvDocument) Set(name, value string) error {
+	if !isValidName(name) {
+		return &ErrSyntax{EnvVar: name, Reason: "invalid variable name"}
+	}
+
+	def, ok := doc.lastDef(name)
+	if !ok {
+		raw, ok := doc.quote(value, 0)
+		if !ok {
+			return &ErrSyntax{EnvVar: name, Reason: "value cannot be quoted"}
+		}
+
+		src := doc.src
+		if src != "" && !strings.HasSuffix(src, "\n") {
+			src += doc.newline()
+		}
+		return doc.parse(src + name + "=" + raw + doc.newline())
+	}
+
+	old := doc.src[def.valueStart:def.valueEnd]
+	start := def.valueStart + len(old) - len(strings.TrimLeft(old, " \t"))
+
+	var current byte
+	if start < def.valueEnd {
+		current = doc.src[start]
+	}
+
+	raw, ok := doc.quote(value, current)
+	if !ok {
+		return &ErrSyntax{EnvVar: name, Line: def.line, Reason: "value cannot be quoted"}
+	}
+
+	if !strings.Contains(doc.src[def.nameEnd:def.valueStart], "=") {
+		// naked variable
+		return doc.parse(doc.src[:def.nameEnd] + "=" + raw + doc.src[def.nameEnd:])
+	}
+
+	return doc.parse(doc.src[:start] + raw + doc.src[def.valueEnd:])
+}
+
+// Delete removes all definitions of the variable with name, including
+// comments on the same lines, and returns whether the variable was defined.
+// Comments on the lines before are kept.
+func (doc *DotEnvDocument) Delete(name string) (bool, error) {
+	src := doc.src
+	found := false
+
+	for i := len(doc.defs) - 1; i >= 0; i-- {
+		def := doc.defs[i]
+		if def.name != name {
+			continue
+		}
+		found = true
+
+		start := strings.LastIndexByte(src[:def.nameStart], '\n') + 1
+		if i > 0 && start < doc.defs[i-1].valueEnd {
+			start = doc.defs[i-1].valueEnd
+		}
+
+		end := len(src)
+		if n := strings.IndexByte(src[def.valueEnd:], '\n'); n >= 0 {
+			end = def.valueEnd + n + 1
+		}
+		if i+1 < len(doc.defs) && end > doc.defs[i+1].nameStart {
+			end = doc.defs[i+1].nameStart
+		}
+
+		src = src[:start] + src[end:]
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	return true, doc.parse(src)
+}
+
+// Rename renames all definitions of the variable with name to newName.
+//
+// Returns ErrMissing when the variable is not defined, and ErrSyntax when
+// newName is not a valid variable name or is already defined.
+func (doc *DotEnvDocument) Rename(name, newName string) error {
+	if _, ok := doc.lastDef(name); !ok {
+		return &ErrMissing{EnvVar: name}
+	}
+
+	if !isValidName(newName) {
+		return &ErrSyntax{EnvVar: newName, Reason: "invalid variable name"}
+	}
+
+	if def, ok := doc.lastDef(newName); ok {
+		return &ErrSyntax{EnvVar: newName, Line: def.line, Reason: "variable already defined"}
+	}
+
+	src := doc.src
+	for i := len(doc.defs) - 1; i >= 0; i-- {
+		if def := doc.defs[i]; def.name == name {
+			src = src[:def.nameStart] + newName + src[def.nameEnd:]
+		}
+	}
+
+	return doc.parse(src)
+}
+
+// String returns the text of doc.
+func (doc *DotEnvDocument) String() string {
+	return doc.src
+}
+
+// WriteTo writes the text of doc to w.
+func (doc *DotEnvDocument) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, doc.src)
+	return int64(n), err
+}
+
+// lastDef returns the last definition of the variable with name.
+func (doc *DotEnvDocument) lastDef(name string) (dotEnvDef, bool) {
+	for i := len(doc.defs) - 1; i >= 0; i-- {
+		if doc.defs[i].name == name {
+			return doc.defs[i], true
+		}
+	}
+	return dotEnvDef{}, false
+}
+
+// newline returns the line ending used by doc.
+func (doc *DotEnvDocument) newline() string {
+	if strings.Contains(doc.src, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// quote returns value as it is written in doc, preferably using quote. The
+// value is written without quotes when possible, or using single quotes,
+// which are not expanded, then double quotes, and back quotes when supported
+// by the dialect. Returns false when value cannot be written without using
+// escapes.
+func (doc *DotEnvDocument) quote(value string, quote byte) (string, bool) {
+	quotes := doc.newScanner().quotes
+
+	var candidates []byte
+	if quote == 0 || quotes[rune(quote)] {
+		candidates = append(candidates, quote)
+	}
+	candidates = append(candidates, 0, '\'', '"', '`')
+
+	for _, q := range candidates {
+		if q != 0 && !quotes[rune(q)] {
+			continue
+		}
+		if canQuote(value, q) {
+			if q == 0 {
+				return value, true
+			}
+			return string(q) + value + string(q), true
+		}
+	}
+
+	return "", false
+}
+
+// canQuote returns whether value can be written as-is using quote, or
+// without quotes when quote is 0.
+func canQuote(value string, quote byte) bool {
+	if strings.ContainsAny(value, "\r\n") {
+		return false
+	}
+
+	switch quote {
+	case 0:
+		return strings.TrimSpace(value) == value && !strings.ContainsAny(value, "#'\"`\\$")
+	case '\'':
+		return !strings.ContainsRune(value, '\'') && !strings.HasSuffix(value, `\`)
+	default:
+		return !strings.ContainsAny(value, string(quote)+`\$`)
+	}
+}
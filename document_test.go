@@ -0,0 +1,217 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+)
+
+func TestDotEnvDocument(t *testing.T) {
+	t.Run("unmodified documents are identical", func(t *testing.T) {
+		for _, c := range []struct {
+			path  string
+			parse func(path string, options ...Option) (*DotEnvDocument, error)
+		}{
+			{"_test_data/js.env", ParseNodeJSDotEnvDocumentFromFile},
+			{"_test_data/py.env", ParseDjangoDotEnvDocumentFromFile},
+			{"_test_data/compose.env", ParseDockerComposeDotEnvDocumentFromFile},
+		} {
+			t.Run(c.path, func(t *testing.T) {
+				exp, err := os.ReadFile(c.path)
+				xt.OK(t, err)
+
+				doc, err := c.parse(c.path)
+				xt.OK(t, err)
+				xt.Eq(t, string(exp), doc.String())
+
+				var buf bytes.Buffer
+				_, err = doc.WriteTo(&buf)
+				xt.OK(t, err)
+				xt.Eq(t, string(exp), buf.String())
+			})
+		}
+	})
+
+	t.Run("variables with their lines", func(t *testing.T) {
+		doc, err := ParseDjangoDotEnvDocument(strings.NewReader("# comment\nNAKED\n\nNAME = 'alice' # user\n"))
+		xt.OK(t, err)
+		xt.Eq(t, []EnvVar{
+			{Name: "NAKED", Naked: true, Line: 2},
+			{Name: "NAME", Value: "alice", Line: 4},
+		}, doc.Vars())
+	})
+
+	t.Run("set keeps comments and quotes", func(t *testing.T) {
+		doc, err := ParseNodeJSDotEnvDocument(strings.NewReader(
+			"# database\nexport DB_USER = 'alice' # owner\nDB_PASSWORD=\"old\"\r\nDB_NAME=app\n"))
+		xt.OK(t, err)
+
+		xt.OK(t, doc.Set("DB_USER", "bob"))
+		xt.OK(t, doc.Set("DB_PASSWORD", "n3w secret"))
+		xt.OK(t, doc.Set("DB_NAME", "my app"))
+		xt.Eq(t, "# database\nexport DB_USER = 'bob' # owner\nDB_PASSWORD=\"n3w secret\"\r\nDB_NAME=my app\n",
+			doc.String())
+
+		v, ok := doc.Get("DB_NAME")
+		xt.Assert(t, ok)
+		xt.Eq(t, EnvVar{Name: "DB_NAME", Value: "my app", Line: 4}, v)
+	})
+
+	t.Run("set changes last definition", func(t *testing.T) {
+		doc, err := ParseNodeJSDotEnvDocument(strings.NewReader("KEY=first\nKEY=second\n"))
+		xt.OK(t, err)
+
+		xt.OK(t, doc.Set("KEY", "third"))
+		xt.Eq(t, "KEY=first\nKEY=third\n", doc.String())
+	})
+
+	t.Run("set quotes when needed", func(t *testing.T) {
+		var cases = map[string]string{
+			"plain":       "KEY=plain\n",
+			"":            "KEY=\n",
+			" spaces ":    "KEY=' spaces '\n",
+			"a#b":         "KEY='a#b'\n",
+			"$HOME":       "KEY='$HOME'\n",
+			`C:\temp`:     `KEY='C:\temp'` + "\n",
+			"it's":        "KEY=\"it's\"\n",
+			`trailing\`:   "",
+			"quote'\"":    "",
+			"multi\nline": "",
+		}
+
+		for value, exp := range cases {
+			t.Run(value, func(t *testing.T) {
+				doc, err := ParseDjangoDotEnvDocument(strings.NewReader(""))
+				xt.OK(t, err)
+
+				err = doc.Set("KEY", value)
+				if exp == "" {
+					xt.KO(t, err)
+					xt.Eq(t, "KEY: syntax error (value cannot be quoted)", err.Error())
+					return
+				}
+				xt.OK(t, err)
+				xt.Eq(t, exp, doc.String())
+
+				v, ok := doc.Lookup("KEY")
+				xt.Assert(t, ok)
+				xt.Eq(t, value, v)
+			})
+		}
+	})
+
+	t.Run("set using back quotes", func(t *testing.T) {
+		doc, err := ParseNodeJSDotEnvDocument(strings.NewReader(""))
+		xt.OK(t, err)
+
+		xt.OK(t, doc.Set("KEY", `it's "quoted"`))
+		xt.Eq(t, "KEY=`it's \"quoted\"`\n", doc.String())
+
+		v, ok := doc.Lookup("KEY")
+		xt.Assert(t, ok)
+		xt.Eq(t, `it's "quoted"`, v)
+
+		// back-quoted values are expanded by dotenv-expand
+		err = doc.Set("KEY", `it's "$HOME"`)
+		xt.KO(t, err)
+		xt.Eq(t, "line 1: syntax error (value cannot be quoted)", err.Error())
+	})
+
+	t.Run("set adds variable at the end", func(t *testing.T) {
+		doc, err := ParseDockerComposeDotEnvDocument(strings.NewReader("# comment\r\nA=1"))
+		xt.OK(t, err)
+
+		xt.OK(t, doc.Set("B", "2"))
+		xt.Eq(t, "# comment\r\nA=1\r\nB=2\r\n", doc.String())
+		xt.Eq(t, []string{"A", "B"}, doc.Names())
+	})
+
+	t.Run("set naked variable", func(t *testing.T) {
+		doc, err := ParseDjangoDotEnvDocument(strings.NewReader("# comment\nNAKED\r\nOTHER=1\n"))
+		xt.OK(t, err)
+
+		xt.OK(t, doc.Set("NAKED", "value"))
+		xt.Eq(t, "# comment\nNAKED=value\r\nOTHER=1\n", doc.String())
+	})
+
+	t.Run("Docker Compose naked variable", func(t *testing.T) {
+		for _, set := range []bool{false, true} {
+			unsetEnv(t, "ENVS_DOC_NAKED")
+			if set {
+				t.Setenv("ENVS_DOC_NAKED", "os")
+			}
+
+			doc, err := ParseDockerComposeDotEnvDocument(strings.NewReader("# c\nENVS_DOC_NAKED\nA=1\n"))
+			xt.OK(t, err)
+			xt.OK(t, doc.Set("ENVS_DOC_NAKED", "v"))
+			xt.Eq(t, "# c\nENVS_DOC_NAKED=v\nA=1\n", doc.String())
+
+			doc, err = ParseDockerComposeDotEnvDocument(strings.NewReader("# c\nENVS_DOC_NAKED\nA=1\n"))
+			xt.OK(t, err)
+			deleted, err := doc.Delete("ENVS_DOC_NAKED")
+			xt.OK(t, err)
+			xt.Assert(t, deleted)
+			xt.Eq(t, "# c\nA=1\n", doc.String())
+		}
+	})
+
+	t.Run("set invalid name", func(t *testing.T) {
+		doc, err := ParseDjangoDotEnvDocument(strings.NewReader(""))
+		xt.OK(t, err)
+
+		err = doc.Set("NOT VALID", "value")
+		xt.KO(t, err)
+		xt.Eq(t, "NOT VALID: syntax error (invalid variable name)", err.Error())
+		xt.Eq(t, "", doc.String())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		doc, err := ParseNodeJSDotEnvDocument(strings.NewReader(
+			"# keep\nKEY=first # gone\nOTHER='multi\nline'\nKEY=\"second\"\n\n# end\n"))
+		xt.OK(t, err)
+
+		deleted, err := doc.Delete("KEY")
+		xt.OK(t, err)
+		xt.Assert(t, deleted)
+		xt.Eq(t, "# keep\nOTHER='multi\nline'\n\n# end\n", doc.String())
+		xt.Eq(t, []EnvVar{{Name: "OTHER", Value: "multi\nline", Line: 2}}, doc.Vars())
+
+		deleted, err = doc.Delete("KEY")
+		xt.OK(t, err)
+		xt.Assert(t, !deleted)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		doc, err := ParseNodeJSDotEnvDocument(strings.NewReader("export OLD=1\nOTHER=2\nOLD = 3 # note\n"))
+		xt.OK(t, err)
+
+		xt.OK(t, doc.Rename("OLD", "NEW"))
+		xt.Eq(t, "export NEW=1\nOTHER=2\nNEW = 3 # note\n", doc.String())
+		xt.Eq(t, []string{"NEW", "OTHER"}, doc.Names())
+
+		err = doc.Rename("OLD", "NEW")
+		var errMissing *ErrMissing
+		xt.Assert(t, errors.As(err, &errMissing))
+		xt.Eq(t, "OLD: variable not defined", err.Error())
+
+		err = doc.Rename("NEW", "OTHER")
+		xt.KO(t, err)
+		xt.Eq(t, "line 2: syntax error (variable already defined)", err.Error())
+
+		err = doc.Rename("NEW", "1NVALID")
+		xt.KO(t, err)
+		xt.Eq(t, "1NVALID: syntax error (invalid variable name)", err.Error())
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := ParseNodeJSDotEnvDocument(strings.NewReader("KEY='not closed"))
+		xt.KO(t, err)
+		xt.Eq(t, "line 1: syntax error (missing closing quote)", err.Error())
+	})
+}
@@ -5,27 +5,74 @@ package envs
 import (
 	"bufio"
 	"io"
-	"regexp"
+	"os"
+	"slices"
+	"strings"
 	"text/scanner"
+	"unicode/utf8"
 )
 
-var reUnescapeNewLines = regexp.MustCompile(`(\\r)?\\n`)
+// expansionMode defines how references to other variables, like `$VAR`,
+// within values are expanded.
+type expansionMode int
+
+const (
+	expandNone         expansionMode = iota
+	expandDotEnvExpand               // after parsing, following NPM package dotenv-expand
+	expandDjango                     // while parsing, following django-dotenv
+	expandCompose                    // while parsing, following Docker Compose
+)
+
+// exportMode defines how the `export` keyword prefixing variable names,
+// like in shell scripts, is handled.
+type exportMode int
+
+const (
+	exportNone   exportMode = iota // keyword is not supported and part of the name
+	exportStrip                    // keyword is removed
+	exportReject                   // keyword is a syntax error
+)
 
 type dotEnvScanner struct {
 	src     *bufio.Reader
 	ch      rune
 	vars    envVarMap
+	names   []string       // names of the variables in the order they were defined
+	lines   map[string]int // line on which each variable is defined
 	line    int
+	offset  int         // offset in bytes after ch
+	defs    []dotEnvDef // definitions of the variables in the order found
+	def     dotEnvDef   // definition being scanned
 	lastErr error
 
 	allowNaked        bool // variables without value and =-sign
+	inheritNaked      bool // naked variables get their value from the OS environment
+	export            exportMode
 	quotes            map[rune]bool
+	quotesAtStart     bool          // quotes only start a quoted value at its beginning
+	escapedQuotes     map[rune]bool // quotes which can be escaped using a backslash
 	unsupportedQuotes map[rune]bool
-	expandNewlines    map[rune]bool
+	unescape          map[rune]func(string) string // processes escapes of values, including the quotes
+	commentAfterSpace bool                         // inline comments of unquoted values must follow a space
+	expansion         expansionMode
+	lookupEnv         func(string) (string, bool) // looks up OS variables while expanding
+
+	parseRules func(ds *dotEnvScanner) error // replaces the rules of parse, for example, for systemd
+}
+
+// dotEnvDef is where a variable is defined within the source. Offsets are
+// in bytes; the value excludes surrounding whitespace and comments.
+type dotEnvDef struct {
+	name       string
+	line       int
+	nameStart  int
+	nameEnd    int
+	valueStart int
+	valueEnd   int
 }
 
 func (ds *dotEnvScanner) next() bool {
-	r, _, err := ds.src.ReadRune()
+	r, size, err := ds.src.ReadRune()
 	if err != nil {
 		if err != io.EOF {
 			ds.lastErr = err
@@ -35,6 +82,7 @@ func (ds *dotEnvScanner) next() bool {
 		return false
 	}
 	ds.ch = r
+	ds.offset += size
 
 	if ds.ch == '\n' {
 		ds.line++
@@ -51,6 +99,14 @@ func (ds *dotEnvScanner) parse(r io.Reader) error {
 	ds.src = bufio.NewReader(r)
 	ds.line = 1
 	ds.vars = envVarMap{}
+	ds.names = nil
+	ds.lines = map[string]int{}
+	ds.offset = 0
+	ds.defs = nil
+
+	if ds.parseRules != nil {
+		return ds.parseRules(ds)
+	}
 
 	for ds.next() {
 		switch ds.ch {
@@ -60,20 +116,48 @@ func (ds *dotEnvScanner) parse(r io.Reader) error {
 			ds.consumeRestLine()
 			continue
 		default:
+			line := ds.line
+			ds.def = dotEnvDef{line: line, nameStart: ds.offset - utf8.RuneLen(ds.ch), nameEnd: ds.offset}
 			variable, naked, err := ds.handleName()
 			if err != nil {
 				return err
 			}
 
+			ds.def.name = variable
+			ds.def.valueStart, ds.def.valueEnd = ds.def.nameEnd, ds.def.nameEnd
+
+			var value *string
+			if naked && ds.inheritNaked {
+				v, ok := ds.lookupOS(variable)
+				if !ok {
+					// not set, but the definition is part of the source
+					ds.defs = append(ds.defs, ds.def)
+					continue
+				}
+				v = quoteRaw(v, false)
+				value = &v
+			}
+
+			ds.define(variable, line)
+
 			if !naked {
-				value, err := ds.handleValue()
+				v, err := ds.handleValue()
 				if err != nil {
 					return err
 				}
-				ds.vars[variable] = &value
-			} else {
-				ds.vars[variable] = nil
+				switch ds.expansion {
+				case expandDjango:
+					v = ds.expandDjango(v)
+				case expandCompose:
+					if v, err = ds.expandCompose(variable, v); err != nil {
+						return err
+					}
+				}
+				value = &v
 			}
+
+			ds.vars[variable] = value
+			ds.defs = append(ds.defs, ds.def)
 		}
 
 		if ds.lastErr != nil {
@@ -81,9 +165,46 @@ func (ds *dotEnvScanner) parse(r io.Reader) error {
 		}
 	}
 
+	if ds.expansion == expandDotEnvExpand {
+		e := newExpander(ds.vars, ds.lines)
+		if ds.lookupEnv != nil {
+			e.lookupEnv = ds.lookupEnv
+		}
+		return e.expandAll()
+	}
+
 	return nil
 }
 
+// configure applies options which change how the source is parsed.
+func (ds *dotEnvScanner) configure(options []Option) {
+	var c decodeConfig
+	for _, o := range options {
+		o(&c)
+	}
+
+	if c.rejectExport {
+		ds.export = exportReject
+	}
+}
+
+// define records that variable name is defined on line, keeping the order
+// in which variables are first defined.
+func (ds *dotEnvScanner) define(name string, line int) {
+	if _, ok := ds.lines[name]; !ok {
+		ds.names = append(ds.names, name)
+	}
+	ds.lines[name] = line
+}
+
+// lookupOS looks up variable name in the OS environment.
+func (ds *dotEnvScanner) lookupOS(name string) (string, bool) {
+	if ds.lookupEnv != nil {
+		return ds.lookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
 func (ds *dotEnvScanner) consumeRestLine() {
 	for ds.next() {
 		if ds.ch == '\n' {
@@ -103,7 +224,26 @@ next:
 				return "", false, &ErrSyntax{Line: ds.line - 1, Reason: "naked variable"}
 			}
 			return variable, true, nil
-		case ' ':
+		case ' ', '\t':
+			if ds.export != exportNone && variable == "export" {
+				for ds.next() && (ds.ch == ' ' || ds.ch == '\t') {
+				}
+				switch ds.ch {
+				case '=':
+					break next
+				case '\r', '\n':
+					return "", false, &ErrSyntax{Line: ds.line - 1, Reason: "invalid variable name"}
+				case scanner.EOF:
+					return "", false, &ErrSyntax{Line: ds.line, Reason: "invalid variable name"}
+				}
+				if ds.export == exportReject {
+					return "", false, &ErrSyntax{Line: ds.line, Reason: "export keyword not allowed"}
+				}
+				variable = string(ds.ch)
+				ds.def.nameStart, ds.def.nameEnd = ds.offset-utf8.RuneLen(ds.ch), ds.offset
+				continue
+			}
+
 			for ds.next() {
 				if ds.ch == '=' {
 					break
@@ -117,6 +257,7 @@ next:
 			break next
 		default:
 			variable += string(ds.ch)
+			ds.def.nameEnd = ds.offset
 		}
 	}
 
@@ -125,27 +266,32 @@ next:
 
 func (ds *dotEnvScanner) handleValue() (string, error) {
 	var value string
+	ds.def.valueStart, ds.def.valueEnd = ds.offset, ds.offset
 next:
 	for ds.next() {
 		switch {
-		case ds.quotes[ds.ch]:
+		case ds.quotes[ds.ch] && (!ds.quotesAtStart || strings.TrimSpace(value) == ""):
 			q := ds.ch
 			var err error
 			if value, err = ds.handleQuotedValue(); err != nil {
 				ds.lastErr = err
-			} else if ds.expandNewlines[q] {
-				value = reUnescapeNewLines.ReplaceAllString(value, "\n")
+			} else if unescape := ds.unescape[q]; unescape != nil {
+				value = unescape(value)
 			}
+			ds.def.valueEnd = ds.offset
 			break next
-		case ds.ch == '#':
+		case ds.ch == '#' && (!ds.commentAfterSpace || value == "" || isSpace(value[len(value)-1])):
 			ds.consumeRestLine()
 			break next
 		case ds.ch == '\n':
 			break next
-		case ds.unsupportedQuotes[ds.ch]:
+		case ds.unsupportedQuotes[ds.ch] && (!ds.quotesAtStart || strings.TrimSpace(value) == ""):
 			return "", &ErrSyntax{Line: ds.line, Reason: "unsupported quote"}
 		default:
 			value += string(ds.ch)
+			if ds.ch != ' ' && ds.ch != '\t' && ds.ch != '\r' {
+				ds.def.valueEnd = ds.offset
+			}
 		}
 	}
 
@@ -155,9 +301,15 @@ next:
 func (ds *dotEnvScanner) handleQuotedValue() (string, error) {
 	quote := ds.ch
 	value := string(ds.ch)
+	escaped := false
 	for ds.next() {
 		value += string(ds.ch)
-		if ds.ch == quote {
+		switch {
+		case escaped:
+			escaped = false
+		case ds.ch == '\\' && ds.escapedQuotes[quote]:
+			escaped = true
+		case ds.ch == quote:
 			return value, nil
 		}
 	}
@@ -165,17 +317,155 @@ func (ds *dotEnvScanner) handleQuotedValue() (string, error) {
 	return "", &ErrSyntax{Line: ds.line, Reason: "missing closing quote"}
 }
 
-func dotEnvToStruct(s *dotEnvScanner, dest any, r io.Reader) error {
+// dotEnvToEnv parses r using scanner s and returns the variables as Env.
+func dotEnvToEnv(s *dotEnvScanner, r io.Reader, options ...Option) (*Env, error) {
+	s.configure(options)
 	if err := s.parse(r); err != nil {
-		return err
+		return nil, err
+	}
+
+	return s.env()
+}
+
+// dotEnvFileToEnv parses the file with path using scanner s and returns the
+// variables as Env. Syntax errors report path.
+func dotEnvFileToEnv(s *dotEnvScanner, path string, options ...Option) (*Env, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, &ErrReadingFile{FilePath: path, Err: err}
+	}
+	defer func() { _ = f.Close() }()
+
+	env, err := dotEnvToEnv(s, f, options...)
+	if e, ok := err.(*ErrSyntax); ok {
+		e.FilePath = path
 	}
+	return env, err
+}
+
+// env returns the variables parsed by ds as Env.
+func (ds *dotEnvScanner) env() (*Env, error) {
+	env := &Env{}
+	for _, name := range ds.names {
+		v := EnvVar{Name: name, Line: ds.lines[name], Naked: ds.vars[name] == nil}
 
-	if err := reflectMapToStruct(s.vars, dest); err != nil {
+		if !v.Naked {
+			var err error
+			if v.Value, err = unquote(name, strings.TrimSpace(*ds.vars[name])); err != nil {
+				if e, ok := err.(*ErrSyntax); ok {
+					e.Line = v.Line
+				}
+				return nil, err
+			}
+		}
+
+		env.add(v)
+	}
+
+	return env, nil
+}
+
+// loadDotEnv parses the dot-env files with paths using parse and sets the
+// variables in the OS environment. When paths is empty, the file `.env` in
+// the current working directory is read.
+// Existing OS variables are only overwritten when overload is true. When
+// the same variable is defined in multiple files, the first definition is
+// used, or the last when overload is true.
+// Naked variables are not set. Nothing is set when a file cannot be parsed.
+func loadDotEnv(parse func(path string, options ...Option) (*Env, error), overload bool, paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+
+	merged := &Env{}
+	for _, p := range paths {
+		env, err := parse(p)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range env.vars {
+			if v.Naked {
+				continue
+			}
+			if _, ok := merged.Get(v.Name); ok && !overload {
+				continue
+			}
+			merged.add(v)
+		}
+	}
+
+	for _, v := range merged.vars {
+		if _, ok := os.LookupEnv(v.Name); ok && !overload {
+			continue
+		}
+		if err := os.Setenv(v.Name, v.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dotEnvToStruct parses r using scanner s and stores the variables in the
+// struct dest. The path is the file r reads from, and is empty when unknown.
+func dotEnvToStruct(s *dotEnvScanner, dest any, r io.Reader, path string, options ...Option) error {
+	s.configure(options)
+	if err := s.parse(r); err != nil {
 		if e, ok := err.(*ErrSyntax); ok {
-			e.Line = s.line
-			return e
+			e.FilePath = path
 		}
 		return err
 	}
-	return nil
+
+	source := path
+	if source == "" {
+		source = sourceDotEnv
+	}
+
+	err := reflectMapToStruct(s.vars, dest, append(slices.Clip(options), withOrigin(func(name string) origin {
+		return origin{source: source, path: path, line: s.lines[name]}
+	}))...)
+	eachErrSyntax(err, func(e *ErrSyntax) {
+		e.Line = s.lines[e.EnvVar]
+		e.FilePath = path
+	})
+
+	return err
+}
+
+// readDotEnvFile parses the file with path using scanner s configured with
+// options, and returns the variables with the lines on which they are defined.
+func readDotEnvFile(s *dotEnvScanner, path string, options []Option) (envVarMap, map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, &ErrReadingFile{FilePath: path, Err: err}
+	}
+	defer func() { _ = f.Close() }()
+
+	s.configure(options)
+	if err := s.parse(f); err != nil {
+		return nil, nil, err
+	}
+
+	return s.vars, s.lines, nil
+}
+
+// eachErrSyntax calls fn for err when it is ErrSyntax, or for each
+// ErrSyntax collected when err is ErrDecoding.
+func eachErrSyntax(err error, fn func(e *ErrSyntax)) {
+	errs := []error{err}
+	if e, ok := err.(*ErrDecoding); ok {
+		errs = e.Errs
+	}
+
+	for _, e := range errs {
+		if e, ok := e.(*ErrSyntax); ok {
+			fn(e)
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
 }
@@ -0,0 +1,61 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"io"
+	"os"
+)
+
+// DockerComposeDotEnv reads environment variables from r and stores them in
+// struct dest according to the rules Docker Compose uses for files given
+// using env_file, see https://docs.docker.com/compose/environment-variables/env-file/.
+//
+// Unlike the NodeJS and Django dialects:
+//   - variable names can be prefixed with `export`
+//   - inline comments of unquoted values must follow a space, for example,
+//     `VAR=VAL#1` results in `VAL#1`
+//   - quotes can be escaped using a backslash, and double-quoted values
+//     support the escapes `\n`, `\r`, `\t`, `\\`, `\"`, and `\$`
+//   - naked variables get their value from the OS environment, and are
+//     not set when not available
+//
+// References to variables are expanded within unquoted and double-quoted
+// values following Compose's interpolation, including `${VAR:?error}` and
+// `${VAR:+replacement}`. Variables defined earlier in the file take precedence
+// over those of the OS environment. A literal `$` is written as `$$`.
+func DockerComposeDotEnv(dest any, r io.Reader, options ...Option) error {
+	return dotEnvToStruct(newDockerComposeScanner(), dest, r, "", options...)
+}
+
+// DockerComposeDotEnvFromFile reads environment variables from a file with path
+// and stores them in struct dest. See DockerComposeDotEnv() for further details.
+func DockerComposeDotEnvFromFile(dest any, path string, options ...Option) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return &ErrReadingFile{FilePath: path, Err: err}
+	}
+	defer func() { _ = f.Close() }()
+
+	return dotEnvToStruct(newDockerComposeScanner(), dest, f, path, options...)
+}
+
+// newDockerComposeScanner returns a scanner for Docker Compose env_file files.
+func newDockerComposeScanner() *dotEnvScanner {
+	return &dotEnvScanner{
+		allowNaked:   true,
+		inheritNaked: true,
+		export:       exportStrip,
+		quotes: map[rune]bool{
+			'"':  true,
+			'\'': true,
+		},
+		quotesAtStart: true,
+		escapedQuotes: map[rune]bool{
+			'"':  true,
+			'\'': true,
+		},
+		commentAfterSpace: true,
+		expansion:         expandCompose,
+	}
+}
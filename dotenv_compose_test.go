@@ -0,0 +1,160 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+)
+
+func TestDockerComposeDotEnv(t *testing.T) {
+	lookupEnv := func(name string) (string, bool) {
+		v, ok := map[string]string{
+			"OS_ONLY": "from os",
+			"OTHER":   "other from os",
+			"EMPTY":   "",
+		}[name]
+		return v, ok
+	}
+
+	t.Run("corpus", func(t *testing.T) {
+		f, err := os.Open("_test_data/compose.env")
+		xt.OK(t, err)
+		defer func() { _ = f.Close() }()
+
+		s := newDockerComposeScanner()
+		s.lookupEnv = lookupEnv
+		env, err := dotEnvToEnv(s, f)
+		xt.OK(t, err)
+
+		exp := map[string]string{
+			"VAR_UNQUOTED":          "VAL",
+			"VAR_DOUBLE":            "VAL",
+			"VAR_SINGLE":            "VAL",
+			"COMMENT_AFTER_SPACE":   "VAL",
+			"COMMENT_NO_SPACE":      "VAL# not a comment",
+			"QUOTED_HASH":           "VAL # not a comment",
+			"QUOTED_COMMENT":        "VAL",
+			"SINGLE_LITERAL":        "$OTHER",
+			"SINGLE_LITERAL_BRACES": "${OTHER}",
+			"SINGLE_ESCAPED_QUOTE":  "Let's go!",
+			"DOUBLE_ESCAPED_QUOTES": `{"hello": "json"}`,
+			"DOUBLE_TAB":            "some\tvalue",
+			"SINGLE_TAB":            `some\tvalue`,
+			"UNQUOTED_TAB":          `some\tvalue`,
+			"DOUBLE_NEWLINE":        "some\nvalue",
+			"MULTILINE":             "SOME\nVALUE",
+			"EXPORTED":              "exported",
+			"OTHER":                 "other",
+			"INTERPOLATED":          "other/other",
+			"INTERPOLATED_DOUBLE":   "other and from os",
+			"DEFAULT_UNSET":         "default",
+			"DEFAULT_EMPTY":         "default",
+			"DEFAULT_EMPTY_DASH":    "",
+			"REPLACE_SET":           "replaced",
+			"REPLACE_UNSET":         "",
+			"ESCAPED_DOLLAR":        "$OTHER",
+			"ESCAPED_DOLLAR_DOUBLE": "$OTHER",
+			"OS_ONLY":               "from os",
+		}
+
+		for name, want := range exp {
+			t.Run(name, func(t *testing.T) {
+				have, ok := env.Lookup(name)
+				xt.Assert(t, ok, "variable not available")
+				xt.Eq(t, want, have)
+			})
+		}
+
+		t.Run("naked variable not available in OS is not set", func(t *testing.T) {
+			_, ok := env.Get("UNSET_NAKED")
+			xt.Assert(t, !ok)
+		})
+
+		t.Run("line numbers", func(t *testing.T) {
+			v, _ := env.Get("EXPORTED")
+			xt.Eq(t, 25, v.Line)
+			v, _ = env.Get("OS_ONLY")
+			xt.Eq(t, 38, v.Line)
+		})
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		t.Setenv("ENVS_COMPOSE_HOST", "db.example.com")
+
+		dest := &struct {
+			Host *string `envVar:"ENVS_COMPOSE_HOST"`
+			Port int     `envVar:"ENVS_COMPOSE_PORT"`
+			URL  string  `envVar:"ENVS_COMPOSE_URL"`
+		}{}
+		xt.OK(t, DockerComposeDotEnv(dest, strings.NewReader(
+			"ENVS_COMPOSE_HOST\nexport ENVS_COMPOSE_PORT=5432\n"+
+				"ENVS_COMPOSE_URL=\"postgres://${ENVS_COMPOSE_HOST}:${ENVS_COMPOSE_PORT}\"\n")))
+		xt.Eq(t, "db.example.com", *dest.Host)
+		xt.Eq(t, 5432, dest.Port)
+		xt.Eq(t, "postgres://db.example.com:5432", dest.URL)
+	})
+
+	t.Run("from file", func(t *testing.T) {
+		dest := &struct {
+			Unquoted string `envVar:"VAR_UNQUOTED"`
+		}{}
+		xt.OK(t, DockerComposeDotEnvFromFile(dest, "_test_data/compose.env"))
+		xt.Eq(t, "VAL", dest.Unquoted)
+
+		err := DockerComposeDotEnvFromFile(dest, "_test_data/not_available.env")
+		xt.KO(t, err)
+		_, ok := err.(*ErrReadingFile)
+		xt.Assert(t, ok)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var cases = map[string]struct {
+			env string
+			exp string
+		}{
+			"required variable not set": {
+				env: "A=1\nB=${NOT_SET:?must be set}",
+				exp: "line 2: syntax error (required variable NOT_SET is missing a value: must be set)",
+			},
+			"required variable empty": {
+				env: "EMPTY=\nB=${EMPTY:?must not be empty}",
+				exp: "line 2: syntax error (required variable EMPTY is missing a value: must not be empty)",
+			},
+			"missing closing brace": {
+				env: "A=${OTHER",
+				exp: "line 1: syntax error (missing closing brace)",
+			},
+			"invalid modifier": {
+				env: "A=${OTHER:=x}",
+				exp: "line 1: syntax error (invalid variable expansion ${OTHER:=x})",
+			},
+			"missing closing quote": {
+				env: `A="escaped quote \"`,
+				exp: "line 1: syntax error (missing closing quote)",
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				s := newDockerComposeScanner()
+				s.lookupEnv = lookupEnv
+				_, err := dotEnvToEnv(s, strings.NewReader(c.env))
+				xt.KO(t, err)
+				xt.Eq(t, c.exp, err.Error())
+			})
+		}
+
+		t.Run("set but empty with dash modifier", func(t *testing.T) {
+			s := newDockerComposeScanner()
+			s.lookupEnv = lookupEnv
+			env, err := dotEnvToEnv(s, strings.NewReader("A=${EMPTY?must be set}"))
+			xt.OK(t, err)
+			v, _ := env.Lookup("A")
+			xt.Eq(t, "", v)
+		})
+	})
+}
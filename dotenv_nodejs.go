@@ -5,32 +5,86 @@ package envs
 import (
 	"io"
 	"os"
+	"strings"
 )
 
 // NodeJSDotEnv reads environment variables from a file typically called `.env`
 // according to the rules defined by the NPM package https://www.npmjs.com/package/dotenv.
-func NodeJSDotEnv(dest any, r io.Reader) error {
-	ds := &dotEnvScanner{
+// References to other variables within values are expanded following the rules
+// of the NPM package https://www.npmjs.com/package/dotenv-expand.
+func NodeJSDotEnv(dest any, r io.Reader, options ...Option) error {
+	return dotEnvToStruct(newNodeJSScanner(), dest, r, "", options...)
+}
+
+// NodeJSDotEnvFromFile reads environment variables from a file with path and stores
+// them in struct dest. See NodeJSDotEnv() for further details.
+func NodeJSDotEnvFromFile(dest any, path string, options ...Option) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return &ErrReadingFile{FilePath: path, Err: err}
+	}
+	defer func() { _ = f.Close() }()
+
+	return dotEnvToStruct(newNodeJSScanner(), dest, f, path, options...)
+}
+
+// ParseNodeJSDotEnv reads environment variables from r according to the same rules
+// as NodeJSDotEnv(), but returns them as Env instead of storing them in a struct.
+func ParseNodeJSDotEnv(r io.Reader, options ...Option) (*Env, error) {
+	return dotEnvToEnv(newNodeJSScanner(), r, options...)
+}
+
+// ParseNodeJSDotEnvFromFile reads environment variables from a file with path and
+// returns them as Env. See ParseNodeJSDotEnv() for further details.
+func ParseNodeJSDotEnvFromFile(path string, options ...Option) (*Env, error) {
+	return dotEnvFileToEnv(newNodeJSScanner(), path, options...)
+}
+
+// LoadNodeJSDotEnv reads the files with paths according to the same rules
+// as NodeJSDotEnv() and sets the variables in the OS environment using os.Setenv.
+// When no paths are given, the file `.env` in the current working directory
+// is read. When a variable is defined in multiple files, the first definition
+// is used. Naked variables are not set.
+// Like the NPM package dotenv, variables already available in the OS environment
+// are not overwritten; use OverloadNodeJSDotEnv() to do so.
+func LoadNodeJSDotEnv(paths ...string) error {
+	return loadDotEnv(ParseNodeJSDotEnvFromFile, false, paths)
+}
+
+// OverloadNodeJSDotEnv is like LoadNodeJSDotEnv(), but overwrites variables already
+// available in the OS environment. When a variable is defined in multiple
+// files, the last definition is used.
+func OverloadNodeJSDotEnv(paths ...string) error {
+	return loadDotEnv(ParseNodeJSDotEnvFromFile, true, paths)
+}
+
+// newNodeJSScanner returns a scanner for NodeJS dot-env files.
+func newNodeJSScanner() *dotEnvScanner {
+	return &dotEnvScanner{
+		export: exportStrip,
 		quotes: map[rune]bool{
 			'"':  true,
 			'`':  true,
 			'\'': true,
 		},
-		expandNewlines: map[rune]bool{
-			'"': true,
+		escapedQuotes: map[rune]bool{
+			'"':  true,
+			'`':  true,
+			'\'': true,
+		},
+		unescape: map[rune]func(string) string{
+			'"': unescapeNodeJS,
 		},
+		expansion: expandDotEnvExpand,
 	}
-
-	return dotEnvToStruct(ds, dest, r)
 }
 
-// NodeJSDotEnvFromFile reads environment variables from a file with path and stores
-// them in struct dest. See NodeJSDotEnv() for further details.
-func NodeJSDotEnvFromFile(dest any, path string) error {
-	f, err := os.Open(path)
-	if err != nil {
-		return &ErrReadingFile{FilePath: path, Err: err}
-	}
+var nodeJSEscapes = strings.NewReplacer(`\n`, "\n", `\r`, "\r")
 
-	return NodeJSDotEnv(dest, f)
+// unescapeNodeJS expands the escapes `\n` and `\r` within double-quoted values
+// like the NPM package dotenv, which replaces each separately; `\r\n` results
+// in a carriage return followed by a newline. Other escapes, including escaped
+// quotes, are kept as-is.
+func unescapeNodeJS(s string) string {
+	return nodeJSEscapes.Replace(s)
 }
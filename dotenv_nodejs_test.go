@@ -50,6 +50,24 @@ func TestNodeJSDotEnviron(t *testing.T) {
 		xt.Eq(t, "", dest.Empty)
 	})
 
+	t.Run("quoted slice", func(t *testing.T) {
+		r := bytes.NewReader([]byte(`HOSTS="a.example,b.example"`))
+		dest := struct {
+			Hosts []string `envVar:"HOSTS"`
+		}{}
+		xt.OK(t, NodeJSDotEnv(&dest, r))
+		xt.Eq(t, []string{"a.example", "b.example"}, dest.Hosts)
+	})
+
+	t.Run("quoted map", func(t *testing.T) {
+		r := bytes.NewReader([]byte(`LABELS="team:core,tier:gold"`))
+		dest := struct {
+			Labels map[string]string `envVar:"LABELS"`
+		}{}
+		xt.OK(t, NodeJSDotEnv(&dest, r))
+		xt.Eq(t, map[string]string{"team": "core", "tier": "gold"}, dest.Labels)
+	})
+
 	t.Run("multiple lines", func(t *testing.T) {
 		exp := `THIS
 IS
@@ -96,7 +114,7 @@ expanded"`,
 			"Windows newlines expand": {
 				envVar: "MULTI_DOUBLE_QUOTED",
 				have:   `"newline\r\nexpanded"`,
-				exp:    "newline\nexpanded",
+				exp:    "newline\r\nexpanded",
 			},
 		}
 
@@ -178,9 +196,136 @@ STRING=foo`,
 		}
 	})
 
+	t.Run("variable expansion", func(t *testing.T) {
+		xt.OK(t, os.Setenv("OS_VAR_e9dk2", "from OS"))
+		xt.OK(t, os.Setenv("OS_EMPTY_e9dk2", ""))
+		defer func() {
+			xt.OK(t, os.Unsetenv("OS_VAR_e9dk2"))
+			xt.OK(t, os.Unsetenv("OS_EMPTY_e9dk2"))
+		}()
+
+		var cases = map[string]struct {
+			env string
+			exp string
+		}{
+			"plain":                  {env: `BASE=/srv` + "\n" + `V=$BASE/app`, exp: "/srv/app"},
+			"braces":                 {env: `BASE=/srv` + "\n" + `V=${BASE}app`, exp: "/srvapp"},
+			"double quoted":          {env: `BASE="/srv"` + "\n" + `V="${BASE} and $BASE"`, exp: "/srv and /srv"},
+			"backticked":             {env: "BASE=/srv\nV=`$BASE`", exp: "/srv"},
+			"single quoted":          {env: `BASE=/srv` + "\n" + `V='$BASE'`, exp: "$BASE"},
+			"escaped":                {env: `BASE=/srv` + "\n" + `V=\$BASE`, exp: "$BASE"},
+			"escaped double quoted":  {env: `V="cost: \$5"`, exp: "cost: $5"},
+			"lone dollar":            {env: `V=5$ and $1`, exp: "5$ and $1"},
+			"defined later":          {env: `V=${LATER}` + "\n" + `LATER=later`, exp: "later"},
+			"OS environment":         {env: `V=$OS_VAR_e9dk2`, exp: "from OS"},
+			"OS takes precedence":    {env: `OS_VAR_e9dk2=from file` + "\n" + `V=$OS_VAR_e9dk2`, exp: "from OS"},
+			"not set":                {env: `V=[$NOT_SET_e9dk2]`, exp: "[]"},
+			"default not set":        {env: `V=${NOT_SET_e9dk2:-default}`, exp: "default"},
+			"default empty":          {env: `V=${OS_EMPTY_e9dk2:-default}`, exp: "default"},
+			"default set":            {env: `V=${OS_VAR_e9dk2:-default}`, exp: "from OS"},
+			"dash default not set":   {env: `V=${NOT_SET_e9dk2-default}`, exp: "default"},
+			"dash default empty":     {env: `V=${OS_EMPTY_e9dk2-default}`, exp: ""},
+			"nested default":         {env: `BASE=/srv` + "\n" + `V=${NOT_SET_e9dk2:-${BASE}/x}`, exp: "/srv/x"},
+			"chained":                {env: `A=a` + "\n" + `B=${A}b` + "\n" + `V=${B}c`, exp: "abc"},
+			"self reference from OS": {env: `OS_VAR_e9dk2=$OS_VAR_e9dk2!` + "\n" + `V=$OS_VAR_e9dk2`, exp: "from OS"},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				dest := struct {
+					V string `envVar:"V"`
+				}{}
+				xt.OK(t, NodeJSDotEnv(&dest, strings.NewReader(c.env)))
+				xt.Eq(t, c.exp, dest.V)
+			})
+		}
+	})
+
+	t.Run("syntax error: variable expansion", func(t *testing.T) {
+		var cases = map[string]struct {
+			env    string
+			expErr string
+		}{
+			"cycle": {
+				env:    "A=1\nB=${C}\nC=${B}",
+				expErr: "line 2: syntax error (variable expansion cycle B -> C -> B)",
+			},
+			"self reference": {
+				env:    "\nSELF_x9dk3=${SELF_x9dk3}",
+				expErr: "line 2: syntax error (variable expansion cycle SELF_x9dk3 -> SELF_x9dk3)",
+			},
+			"missing closing brace": {
+				env:    "A=1\n\nB=${A",
+				expErr: "line 3: syntax error (missing closing brace)",
+			},
+			"invalid expansion": {
+				env:    "A=${A:=1}",
+				expErr: "line 1: syntax error (invalid variable expansion ${A:=1})",
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				dest := struct{}{}
+				err := NodeJSDotEnv(&dest, strings.NewReader(c.env))
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+			})
+		}
+	})
+
 	t.Run("not readable file", func(t *testing.T) {
 		err := NodeJSDotEnvFromFile(nil, path.Join(os.TempDir(), "829d9klwiwe.env"))
 		xt.KO(t, err)
 		xt.Assert(t, strings.Contains(err.Error(), "no such file or directory"))
 	})
 }
+
+func TestLoadNodeJSDotEnv(t *testing.T) {
+	p := writeDotEnv(t, ".env", "ENVS_LOAD_USER=alice\nENVS_LOAD_HOME=/home/${ENVS_LOAD_USER}\n")
+
+	t.Run("preserve", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOAD_HOME")
+		t.Setenv("ENVS_LOAD_USER", "bob")
+
+		xt.OK(t, LoadNodeJSDotEnv(p))
+		xt.Eq(t, "bob", os.Getenv("ENVS_LOAD_USER"))
+		xt.Eq(t, "/home/bob", os.Getenv("ENVS_LOAD_HOME"))
+	})
+
+	t.Run("overload", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOAD_HOME")
+		t.Setenv("ENVS_LOAD_USER", "bob")
+
+		xt.OK(t, OverloadNodeJSDotEnv(p))
+		xt.Eq(t, "alice", os.Getenv("ENVS_LOAD_USER"))
+		xt.Eq(t, "/home/bob", os.Getenv("ENVS_LOAD_HOME"))
+	})
+}
+
+func TestNodeJSDotEnvExport(t *testing.T) {
+	t.Run("export keyword is removed", func(t *testing.T) {
+		env, err := ParseNodeJSDotEnv(strings.NewReader(
+			"export HOME=/home/alice\nexport\tUSER=alice\nexport   SHELL = /bin/sh\nexport=keyword\n"))
+		xt.OK(t, err)
+		xt.Eq(t, map[string]string{
+			"HOME":   "/home/alice",
+			"USER":   "alice",
+			"SHELL":  "/bin/sh",
+			"export": "keyword",
+		}, env.Map())
+
+		v, _ := env.Get("USER")
+		xt.Eq(t, 2, v.Line)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		dest := &testEnv{}
+		err := NodeJSDotEnv(dest, strings.NewReader("NUMBER=1\nexport STRING=value\n"), AllowExport(false))
+		xt.KO(t, err)
+		xt.Eq(t, "line 2: syntax error (export keyword not allowed)", err.Error())
+
+		_, err = ParseNodeJSDotEnv(strings.NewReader("export=keyword"), AllowExport(false))
+		xt.OK(t, err)
+	})
+}
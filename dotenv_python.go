@@ -5,14 +5,69 @@ package envs
 import (
 	"io"
 	"os"
+	"strings"
 )
 
 // DjangoDotEnv reads environment variables from r and stores them in struct
 // dest according to the rules defined by the django-dotenv project
 // https://github.com/jpadilla/django-dotenv/blob/master/dotenv.py. The variables
 // are stored and available within the dest struct.
-func DjangoDotEnv(dest any, r io.Reader) error {
-	ds := &dotEnvScanner{
+//
+// Like django-dotenv, references to variables using `$VAR` or `${VAR}` are
+// expanded within unquoted and double-quoted values, but not within single-quoted
+// values. Variables defined earlier in the file take precedence over those
+// of the OS environment. A reference can be escaped using a backslash: `\$VAR`.
+func DjangoDotEnv(dest any, r io.Reader, options ...Option) error {
+	return dotEnvToStruct(newDjangoScanner(), dest, r, "", options...)
+}
+
+// DjangoDotEnvFromFile reads environment variables from a file with path and stores
+// them in struct dest. See DjangoDotEnv() for further details.
+func DjangoDotEnvFromFile(dest any, path string, options ...Option) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return &ErrReadingFile{FilePath: path, Err: err}
+	}
+	defer func() { _ = f.Close() }()
+
+	return dotEnvToStruct(newDjangoScanner(), dest, f, path, options...)
+}
+
+// ParseDjangoDotEnv reads environment variables from r according to the same rules
+// as DjangoDotEnv(), but returns them as Env instead of storing them in a struct.
+func ParseDjangoDotEnv(r io.Reader, options ...Option) (*Env, error) {
+	return dotEnvToEnv(newDjangoScanner(), r, options...)
+}
+
+// ParseDjangoDotEnvFromFile reads environment variables from a file with path and
+// returns them as Env. See ParseDjangoDotEnv() for further details.
+func ParseDjangoDotEnvFromFile(path string, options ...Option) (*Env, error) {
+	return dotEnvFileToEnv(newDjangoScanner(), path, options...)
+}
+
+// LoadDjangoDotEnv reads the files with paths according to the same rules
+// as DjangoDotEnv() and sets the variables in the OS environment using os.Setenv.
+// When no paths are given, the file `.env` in the current working directory
+// is read. When a variable is defined in multiple files, the first definition
+// is used. Naked variables are not set.
+// Like django-dotenv, which uses `os.environ.setdefault`, variables already
+// available in the OS environment are not overwritten; use OverloadDjangoDotEnv()
+// to do so.
+func LoadDjangoDotEnv(paths ...string) error {
+	return loadDotEnv(ParseDjangoDotEnvFromFile, false, paths)
+}
+
+// OverloadDjangoDotEnv is like LoadDjangoDotEnv(), but overwrites variables already
+// available in the OS environment. When a variable is defined in multiple
+// files, the last definition is used.
+func OverloadDjangoDotEnv(paths ...string) error {
+	return loadDotEnv(ParseDjangoDotEnvFromFile, true, paths)
+}
+
+// newDjangoScanner returns a scanner for Django dot-env files.
+func newDjangoScanner() *dotEnvScanner {
+	return &dotEnvScanner{
+		export: exportStrip,
 		quotes: map[rune]bool{
 			'"':  true,
 			'\'': true,
@@ -20,22 +75,49 @@ func DjangoDotEnv(dest any, r io.Reader) error {
 		unsupportedQuotes: map[rune]bool{
 			'`': true,
 		},
-		expandNewlines: map[rune]bool{
-			'"': true,
+		escapedQuotes: map[rune]bool{
+			'"':  true,
+			'\'': true,
+		},
+		unescape: map[rune]func(string) string{
+			'"': unescapeDjango,
 		},
 		allowNaked: true,
+		expansion:  expandDjango,
 	}
-
-	return dotEnvToStruct(ds, dest, r)
 }
 
-// DjangoDotEnvFromFile reads environment variables from a file with path and stores
-// them in struct dest. See DjangoDotEnv() for further details.
-func DjangoDotEnvFromFile(dest any, path string) error {
-	f, err := os.Open(path)
-	if err != nil {
-		return &ErrReadingFile{FilePath: path, Err: err}
+// unescapeDjango removes the backslash of all escapes within double-quoted
+// values, except for `\$` which escapes variable expansion, like django-dotenv.
+// For example, `\"` results in `"` and `\\` in `\`.
+//
+// This deviates from django-dotenv for `\n` and `\r\n`, which result in a
+// newline instead of `n` and `rn`, keeping the newline expansion earlier
+// versions of this package provided.
+func unescapeDjango(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(s[i+1:], `r\n`):
+			b.WriteByte('\n')
+			i += 3
+		case s[i+1] == 'n':
+			b.WriteByte('\n')
+			i++
+		case s[i+1] == '$':
+			b.WriteString(`\$`)
+			i++
+		default:
+			b.WriteByte(s[i+1])
+			i++
+		}
 	}
 
-	return DjangoDotEnv(dest, f)
+	return b.String()
 }
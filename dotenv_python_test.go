@@ -27,6 +27,36 @@ func TestPythonDotEnv(t *testing.T) {
 		xt.Eq(t, int64(0), *dest.PtrNumberInt)
 	})
 
+	t.Run("numeric pointer with smaller bit size", func(t *testing.T) {
+		r := bytes.NewReader([]byte("PTR_NUMBER_naked=127"))
+		dest := testEnv{}
+		xt.OK(t, DjangoDotEnv(&dest, r))
+		xt.Eq(t, int8(127), *dest.PtrNumberIntNaked)
+
+		r = bytes.NewReader([]byte("PTR_NUMBER_naked=128"))
+		err := DjangoDotEnv(&dest, r)
+		xt.KO(t, err)
+		xt.Eq(t, "line 1: syntax error (out of range for int8)", err.Error())
+	})
+
+	t.Run("naked variable is available but empty", func(t *testing.T) {
+		r := bytes.NewReader([]byte("HOST\nPORT="))
+		dest := struct {
+			Host *string `envVar:"HOST,required"`
+			Port *int    `envVar:"PORT,required"`
+		}{}
+		xt.OK(t, DjangoDotEnv(&dest, r))
+		xt.Eq(t, nil, dest.Host)
+		xt.Eq(t, 0, *dest.Port)
+
+		r = bytes.NewReader([]byte("HOST\nPORT="))
+		err := DjangoDotEnv(&struct {
+			Host *string `envVar:"HOST,notEmpty"`
+		}{}, r)
+		xt.KO(t, err)
+		xt.Eq(t, "HOST: variable is empty (field Host)", err.Error())
+	})
+
 	t.Run("unquoted strings are trimmed of whitespaces", func(t *testing.T) {
 		xt.Eq(t, "My String", dest.UnquotedString)
 	})
@@ -65,6 +95,24 @@ func TestPythonDotEnv(t *testing.T) {
 		xt.Eq(t, "", dest.Empty)
 	})
 
+	t.Run("quoted slice", func(t *testing.T) {
+		r := bytes.NewReader([]byte(`HOSTS='a.example,b.example'`))
+		dest := struct {
+			Hosts []string `envVar:"HOSTS"`
+		}{}
+		xt.OK(t, DjangoDotEnv(&dest, r))
+		xt.Eq(t, []string{"a.example", "b.example"}, dest.Hosts)
+	})
+
+	t.Run("quoted map", func(t *testing.T) {
+		r := bytes.NewReader([]byte(`LABELS='team:core,tier:gold'`))
+		dest := struct {
+			Labels map[string]string `envVar:"LABELS"`
+		}{}
+		xt.OK(t, DjangoDotEnv(&dest, r))
+		xt.Eq(t, map[string]string{"team": "core", "tier": "gold"}, dest.Labels)
+	})
+
 	t.Run("boolean empty value", func(t *testing.T) {
 		xt.Eq(t, false, *dest.PtrBoolean)
 	})
@@ -201,9 +249,99 @@ expanded"`,
 		}
 	})
 
+	t.Run("variable expansion", func(t *testing.T) {
+		xt.OK(t, os.Setenv("OS_VAR_p8dk3", "from OS"))
+		defer func() { xt.OK(t, os.Unsetenv("OS_VAR_p8dk3")) }()
+
+		// cases based on the tests of django-dotenv
+		var cases = map[string]struct {
+			env string
+			exp string
+		}{
+			"unquoted":               {env: "FOO=bar\nV=$FOO", exp: "bar"},
+			"braces":                 {env: "FOO=bar\nV=${FOO}", exp: "bar"},
+			"double quoted":          {env: "FOO=bar\nV=\"$FOO and ${FOO}\"", exp: "bar and bar"},
+			"single quoted":          {env: "FOO=bar\nV='$FOO'", exp: "$FOO"},
+			"quoted referenced":      {env: "FOO='b a r'\nV=$FOO", exp: "b a r"},
+			"escaped":                {env: "FOO=bar\nV=\\$FOO", exp: "$FOO"},
+			"escaped braces":         {env: "FOO=bar\nV=\"\\${FOO}\"", exp: "${FOO}"},
+			"escaped and unescaped":  {env: "FOO=bar\nV=\\$FOO $FOO", exp: "$FOO bar"},
+			"lone dollar":            {env: "V=5 $ and $", exp: "5 $ and $"},
+			"within text":            {env: "FOO=bar\nV=foo${FOO}baz", exp: "foobarbaz"},
+			"lowercase":              {env: "foo=bar\nV=$foo", exp: "bar"},
+			"OS environment":         {env: "V=$OS_VAR_p8dk3", exp: "from OS"},
+			"file takes precedence":  {env: "OS_VAR_p8dk3=from file\nV=$OS_VAR_p8dk3", exp: "from file"},
+			"not set":                {env: "V=[$NOT_SET_p8dk3]", exp: "[]"},
+			"defined later not used": {env: "V=[$LATER]\nLATER=later", exp: "[]"},
+			"chained":                {env: "A=a\nB=${A}b\nV=${B}c", exp: "abc"},
+			"redefined":              {env: "A=1\nV=$A\nA=2", exp: "1"},
+			"naked is not set":       {env: "NAKED\nV=[$NAKED]", exp: "[]"},
+			"missing closing brace":  {env: "FOO=bar\nV=${FOO", exp: "bar"},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				dest := struct {
+					V string `envVar:"V"`
+				}{}
+				xt.OK(t, DjangoDotEnv(&dest, strings.NewReader(c.env)))
+				xt.Eq(t, c.exp, dest.V)
+			})
+		}
+	})
+
 	t.Run("not readable file", func(t *testing.T) {
 		err := DjangoDotEnvFromFile(nil, path.Join(os.TempDir(), "vnmjkef8qjfi.env"))
 		xt.KO(t, err)
 		xt.Assert(t, strings.Contains(err.Error(), "no such file or directory"))
 	})
 }
+
+func TestLoadDjangoDotEnv(t *testing.T) {
+	p := writeDotEnv(t, ".env", "ENVS_LOAD_USER=alice\nENVS_LOAD_HOME=/home/$ENVS_LOAD_USER\n")
+
+	t.Run("preserve", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOAD_HOME")
+		t.Setenv("ENVS_LOAD_USER", "bob")
+
+		xt.OK(t, LoadDjangoDotEnv(p))
+		xt.Eq(t, "bob", os.Getenv("ENVS_LOAD_USER"))
+		xt.Eq(t, "/home/alice", os.Getenv("ENVS_LOAD_HOME"))
+	})
+
+	t.Run("overload", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOAD_HOME")
+		t.Setenv("ENVS_LOAD_USER", "bob")
+
+		xt.OK(t, OverloadDjangoDotEnv(p))
+		xt.Eq(t, "alice", os.Getenv("ENVS_LOAD_USER"))
+		xt.Eq(t, "/home/alice", os.Getenv("ENVS_LOAD_HOME"))
+	})
+}
+
+func TestDjangoDotEnvExport(t *testing.T) {
+	t.Run("export keyword is removed", func(t *testing.T) {
+		env, err := ParseDjangoDotEnv(strings.NewReader(
+			"export HOME=/home/alice\nexport\tUSER=alice\nexport   SHELL = /bin/sh\nexport=keyword\n"))
+		xt.OK(t, err)
+		xt.Eq(t, map[string]string{
+			"HOME":   "/home/alice",
+			"USER":   "alice",
+			"SHELL":  "/bin/sh",
+			"export": "keyword",
+		}, env.Map())
+
+		v, _ := env.Get("USER")
+		xt.Eq(t, 2, v.Line)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		dest := &testEnv{}
+		err := DjangoDotEnv(dest, strings.NewReader("NUMBER=1\nexport STRING=value\n"), AllowExport(false))
+		xt.KO(t, err)
+		xt.Eq(t, "line 2: syntax error (export keyword not allowed)", err.Error())
+
+		_, err = ParseDjangoDotEnv(strings.NewReader("export=keyword"), AllowExport(false))
+		xt.OK(t, err)
+	})
+}
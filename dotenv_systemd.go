@@ -0,0 +1,229 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// SystemdEnvironmentFile reads environment variables from r and stores them in
+// struct dest according to the rules systemd uses for files given using
+// EnvironmentFile=, see systemd.exec(5):
+//
+//   - lines starting with `#` or `;` are comments; there are no inline comments
+//   - lines without `=` and variables with invalid names are ignored
+//   - leading and trailing whitespace of unquoted values is discarded, but
+//     interior whitespace is preserved
+//   - a backslash preserves the following character in unquoted values, and
+//     a backslash at the end of a line continues the value on the next line
+//   - single-quoted values are used verbatim and can span multiple lines
+//   - double-quoted values can span multiple lines; a backslash followed by
+//     one of "\`$ preserves that character, followed by a newline continues
+//     the line, and is kept as-is otherwise
+//   - quoted and unquoted parts are concatenated, for example, `'a'"b"c` is `abc`
+//
+// Variables are not expanded.
+func SystemdEnvironmentFile(dest any, r io.Reader, options ...Option) error {
+	return dotEnvToStruct(newSystemdScanner(), dest, r, "", options...)
+}
+
+// SystemdEnvironmentFileFromFile reads environment variables from a file with
+// path and stores them in struct dest. See SystemdEnvironmentFile() for further
+// details.
+func SystemdEnvironmentFileFromFile(dest any, path string, options ...Option) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return &ErrReadingFile{FilePath: path, Err: err}
+	}
+	defer func() { _ = f.Close() }()
+
+	return dotEnvToStruct(newSystemdScanner(), dest, f, path, options...)
+}
+
+// newSystemdScanner returns a scanner for systemd environment files.
+func newSystemdScanner() *dotEnvScanner {
+	return &dotEnvScanner{
+		parseRules: (*dotEnvScanner).parseSystemd,
+	}
+}
+
+// systemdState is the state of the scanner while parsing systemd environment
+// files; it follows parse_env_file_internal() of systemd.
+type systemdState int
+
+const (
+	systemdPreKey systemdState = iota
+	systemdKey
+	systemdPreValue
+	systemdValue
+	systemdValueEscape
+	systemdSingleQuoteValue
+	systemdDoubleQuoteValue
+	systemdDoubleQuoteValueEscape
+	systemdComment
+	systemdCommentEscape
+)
+
+// systemdEscapable are the characters a backslash preserves within
+// double-quoted values.
+const systemdEscapable = "\"\\`$"
+
+// parseSystemd parses the source using the rules of systemd environment files.
+func (ds *dotEnvScanner) parseSystemd() error {
+	state := systemdPreKey
+	var key, value strings.Builder
+	var keyLine int
+	var keySpace, valueSpace int // start of trailing whitespace; -1 when none
+
+	store := func() {
+		name := key.String()
+		if keySpace >= 0 {
+			name = name[:keySpace]
+		}
+
+		v := value.String()
+		if state == systemdValue && valueSpace >= 0 {
+			v = v[:valueSpace]
+		}
+
+		if isValidName(name) {
+			v = quoteRaw(v, false)
+			ds.define(name, keyLine)
+			ds.vars[name] = &v
+		}
+	}
+
+	for ds.next() {
+		c := ds.ch
+		newline := c == '\n' || c == '\r'
+		space := c == ' ' || c == '\t' || newline
+
+		switch state {
+		case systemdPreKey:
+			switch {
+			case c == '#' || c == ';':
+				state = systemdComment
+			case !space:
+				state = systemdKey
+				key.Reset()
+				key.WriteRune(c)
+				keyLine = ds.line
+				keySpace = -1
+			}
+		case systemdKey:
+			switch {
+			case newline:
+				state = systemdPreKey // no =-sign
+			case c == '=':
+				state = systemdPreValue
+				value.Reset()
+				valueSpace = -1
+			default:
+				if !space {
+					keySpace = -1
+				} else if keySpace < 0 {
+					keySpace = key.Len()
+				}
+				key.WriteRune(c)
+			}
+		case systemdPreValue:
+			switch {
+			case newline:
+				store()
+				state = systemdPreKey
+			case c == '\'':
+				state = systemdSingleQuoteValue
+			case c == '"':
+				state = systemdDoubleQuoteValue
+			case c == '\\':
+				state = systemdValueEscape
+			case !space:
+				state = systemdValue
+				value.WriteRune(c)
+			}
+		case systemdValue:
+			switch {
+			case newline:
+				store()
+				state = systemdPreKey
+			case c == '\\':
+				state = systemdValueEscape
+				valueSpace = -1
+			default:
+				if !space {
+					valueSpace = -1
+				} else if valueSpace < 0 {
+					valueSpace = value.Len()
+				}
+				value.WriteRune(c)
+			}
+		case systemdValueEscape:
+			state = systemdValue
+			if !newline {
+				value.WriteRune(c)
+			}
+		case systemdSingleQuoteValue:
+			if c == '\'' {
+				state = systemdPreValue
+			} else {
+				value.WriteRune(c)
+			}
+		case systemdDoubleQuoteValue:
+			switch c {
+			case '"':
+				state = systemdPreValue
+			case '\\':
+				state = systemdDoubleQuoteValueEscape
+			default:
+				value.WriteRune(c)
+			}
+		case systemdDoubleQuoteValueEscape:
+			state = systemdDoubleQuoteValue
+			switch {
+			case strings.ContainsRune(systemdEscapable, c):
+				value.WriteRune(c)
+			case !newline:
+				value.WriteRune('\\')
+				value.WriteRune(c)
+			}
+		case systemdComment:
+			switch {
+			case c == '\\':
+				state = systemdCommentEscape
+			case newline:
+				state = systemdPreKey
+			}
+		case systemdCommentEscape:
+			state = systemdComment
+		}
+	}
+
+	if ds.lastErr != nil {
+		return ds.lastErr
+	}
+
+	switch state {
+	case systemdPreValue, systemdValue, systemdValueEscape, systemdSingleQuoteValue,
+		systemdDoubleQuoteValue, systemdDoubleQuoteValueEscape:
+		store()
+	}
+
+	return nil
+}
+
+// isValidName returns whether name is a valid environment variable name
+// consisting of letters, digits, and underscores, not starting with a digit.
+func isValidName(name string) bool {
+	if name == "" || !isNameStart(name[0]) {
+		return false
+	}
+
+	for i := 1; i < len(name); i++ {
+		if !isNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
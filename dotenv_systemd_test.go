@@ -0,0 +1,82 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+)
+
+func TestSystemdEnvironmentFile(t *testing.T) {
+	t.Run("corpus", func(t *testing.T) {
+		f, err := os.Open("_test_data/systemd.env")
+		xt.OK(t, err)
+		defer func() { _ = f.Close() }()
+
+		env, err := dotEnvToEnv(newSystemdScanner(), f)
+		xt.OK(t, err)
+
+		xt.Eq(t, []EnvVar{
+			{Name: "UNQUOTED", Value: "value with   interior space", Line: 4},
+			{Name: "NO_INLINE_COMMENT", Value: "value # not a comment", Line: 5},
+			{Name: "ESCAPED", Value: `back\slash and$dollar`, Line: 6},
+			{Name: "CONTINUED", Value: "first second", Line: 7},
+			{Name: "SINGLE", Value: `verbatim \n $HOME`, Line: 9},
+			{Name: "DOUBLE", Value: "say \"hi\" \\ ` $HOME \\n", Line: 10},
+			{Name: "DOUBLE_CONTINUED", Value: "first second", Line: 11},
+			{Name: "MULTILINE", Value: "line 1\nline 2", Line: 13},
+			{Name: "CONCATENATED", Value: "abc", Line: 15},
+			{Name: "QUOTE_AFTER_VALUE", Value: `it's "fine"`, Line: 16},
+			{Name: "EMPTY", Value: "", Line: 17},
+			{Name: "SPACED_KEY", Value: "spaced", Line: 18},
+			{Name: "REDEFINED", Value: "second", Line: 25},
+			{Name: "LAST", Value: "no newline", Line: 26},
+		}, env.Vars())
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		dest := &struct {
+			Port  int      `envVar:"PORT"`
+			Hosts []string `envVar:"HOSTS"`
+			Motd  string   `envVar:"MOTD"`
+		}{}
+
+		xt.OK(t, SystemdEnvironmentFile(dest, strings.NewReader(
+			"PORT=8080\r\nHOSTS=a.example,\\\n  b.example\nMOTD=\"  Welcome  \"\n")))
+		xt.Eq(t, 8080, dest.Port)
+		xt.Eq(t, []string{"a.example", "b.example"}, dest.Hosts)
+		xt.Eq(t, "  Welcome  ", dest.Motd)
+	})
+
+	t.Run("unterminated quote", func(t *testing.T) {
+		env, err := dotEnvToEnv(newSystemdScanner(), strings.NewReader("A='not closed\n"))
+		xt.OK(t, err)
+		v, _ := env.Lookup("A")
+		xt.Eq(t, "not closed\n", v)
+	})
+
+	t.Run("from file", func(t *testing.T) {
+		dest := &struct {
+			Last string `envVar:"LAST"`
+		}{}
+		xt.OK(t, SystemdEnvironmentFileFromFile(dest, "_test_data/systemd.env"))
+		xt.Eq(t, "no newline", dest.Last)
+
+		err := SystemdEnvironmentFileFromFile(dest, "_test_data/not_available.env")
+		xt.KO(t, err)
+		_, ok := err.(*ErrReadingFile)
+		xt.Assert(t, ok)
+	})
+
+	t.Run("syntax error reports line", func(t *testing.T) {
+		dest := &struct {
+			Port int `envVar:"PORT"`
+		}{}
+		err := SystemdEnvironmentFile(dest, strings.NewReader("; port\nPORT=eighty"))
+		xt.KO(t, err)
+		xt.Eq(t, "line 2: syntax error (number not parsable)", err.Error())
+	})
+}
@@ -4,6 +4,9 @@ package envs
 
 import (
 	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/golistic/xgo/xt"
@@ -16,14 +19,14 @@ func TestDotEnvParse(t *testing.T) {
 	t.Run("spaces before equal sign", func(t *testing.T) {
 		r := bytes.NewReader([]byte(`NUMBER  = 123`))
 		dest := &testEnv{}
-		xt.OK(t, dotEnvToStruct(scanner, dest, r))
+		xt.OK(t, dotEnvToStruct(scanner, dest, r, ""))
 		xt.Eq(t, 123, dest.Number)
 	})
 
 	t.Run("syntax: number not parsable", func(t *testing.T) {
 		r := bytes.NewReader([]byte(`NUMBER=Not a number`))
 		dest := &testEnv{}
-		err := dotEnvToStruct(scanner, dest, r)
+		err := dotEnvToStruct(scanner, dest, r, "")
 		xt.KO(t, err)
 		xt.Eq(t, "line 1: syntax error (number not parsable)", err.Error())
 	})
@@ -31,7 +34,7 @@ func TestDotEnvParse(t *testing.T) {
 	t.Run("syntax: duration not parsable", func(t *testing.T) {
 		r := bytes.NewReader([]byte(`Duration=Not a Duration`))
 		dest := &testEnv{}
-		err := dotEnvToStruct(scanner, dest, r)
+		err := dotEnvToStruct(scanner, dest, r, "")
 		xt.KO(t, err)
 		xt.Eq(t, "line 1: syntax error (not parsable as Go duration string)", err.Error())
 	})
@@ -39,8 +42,228 @@ func TestDotEnvParse(t *testing.T) {
 	t.Run("syntax: boolean not parsable", func(t *testing.T) {
 		r := bytes.NewReader([]byte(`BOOLEAN=Neither true or false`))
 		dest := &testEnv{}
-		err := dotEnvToStruct(scanner, dest, r)
+		err := dotEnvToStruct(scanner, dest, r, "")
 		xt.KO(t, err)
 		xt.Eq(t, "line 1: syntax error (not a valid boolean value)", err.Error())
 	})
+
+	t.Run("all errors report the line of each variable", func(t *testing.T) {
+		r := bytes.NewReader([]byte("NUMBER=Not a number\n\n# comment\nBOOLEAN=maybe\n"))
+		dest := &testEnv{}
+		err := dotEnvToStruct(scanner, dest, r, "", AllErrors(true))
+		xt.KO(t, err)
+		xt.Eq(t, "line 1: syntax error (number not parsable) (field Number)\n"+
+			"line 4: syntax error (not a valid boolean value) (field Boolean)", err.Error())
+	})
+
+	t.Run("errors of files report the file", func(t *testing.T) {
+		p := writeDotEnv(t, ".env", "\nNUMBER=Not a number\n")
+		err := NodeJSDotEnvFromFile(&testEnv{}, p)
+		xt.KO(t, err)
+		xt.Eq(t, p+":2: syntax error (number not parsable)", err.Error())
+
+		p = writeDotEnv(t, ".env", "\nNUMBER='not closed\n")
+		err = DjangoDotEnvFromFile(&testEnv{}, p)
+		xt.KO(t, err)
+		xt.Eq(t, p+":3: syntax error (missing closing quote)", err.Error())
+	})
+}
+
+// writeDotEnv writes content to a file with name within a temporary
+// directory and returns its path.
+func writeDotEnv(t *testing.T, name, content string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), name)
+	xt.OK(t, os.WriteFile(p, []byte(content), 0o600))
+	return p
+}
+
+// unsetEnv removes the OS environment variables with names, restoring
+// them when the test finishes.
+func unsetEnv(t *testing.T, names ...string) {
+	t.Helper()
+
+	for _, name := range names {
+		t.Setenv(name, "")
+		xt.OK(t, os.Unsetenv(name))
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	first := writeDotEnv(t, ".env", "ENVS_LOAD_A=first\nENVS_LOAD_B=first\nENVS_LOAD_NAKED\n")
+	second := writeDotEnv(t, ".env.local", "ENVS_LOAD_B=second\nENVS_LOAD_C=second\n")
+
+	t.Run("existing variables are preserved", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOAD_A", "ENVS_LOAD_B", "ENVS_LOAD_C", "ENVS_LOAD_NAKED")
+		t.Setenv("ENVS_LOAD_A", "os")
+
+		xt.OK(t, loadDotEnv(ParseDjangoDotEnvFromFile, false, []string{first, second}))
+		xt.Eq(t, "os", os.Getenv("ENVS_LOAD_A"))
+		xt.Eq(t, "first", os.Getenv("ENVS_LOAD_B"))
+		xt.Eq(t, "second", os.Getenv("ENVS_LOAD_C"))
+
+		_, ok := os.LookupEnv("ENVS_LOAD_NAKED")
+		xt.Assert(t, !ok, "naked variables are not set")
+	})
+
+	t.Run("overload", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOAD_A", "ENVS_LOAD_B", "ENVS_LOAD_C", "ENVS_LOAD_NAKED")
+		t.Setenv("ENVS_LOAD_A", "os")
+
+		xt.OK(t, loadDotEnv(ParseDjangoDotEnvFromFile, true, []string{first, second}))
+		xt.Eq(t, "first", os.Getenv("ENVS_LOAD_A"))
+		xt.Eq(t, "second", os.Getenv("ENVS_LOAD_B"))
+		xt.Eq(t, "second", os.Getenv("ENVS_LOAD_C"))
+	})
+
+	t.Run("nothing is set when a file fails", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOAD_A", "ENVS_LOAD_B", "ENVS_LOAD_C", "ENVS_LOAD_NAKED")
+		broken := writeDotEnv(t, ".env.broken", "ENVS_LOAD_C='not closed")
+
+		err := loadDotEnv(ParseDjangoDotEnvFromFile, false, []string{first, broken})
+		xt.KO(t, err)
+		xt.Eq(t, broken+":1: syntax error (missing closing quote)", err.Error())
+
+		_, ok := os.LookupEnv("ENVS_LOAD_A")
+		xt.Assert(t, !ok)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		err := loadDotEnv(ParseDjangoDotEnvFromFile, false, []string{"_test_data/not_available.env"})
+		xt.KO(t, err)
+		var errReading *ErrReadingFile
+		xt.Assert(t, errors.As(err, &errReading))
+	})
+}
+
+// TestDotEnvEscapes contrasts how escapes within values are handled by
+// each dialect.
+func TestDotEnvEscapes(t *testing.T) {
+	type expected struct {
+		nodeJS  string
+		django  string
+		compose string
+		systemd string
+	}
+
+	var cases = map[string]struct {
+		env string
+		exp expected
+	}{
+		"escaped double quotes": {
+			env: `V="say \"hi\""`,
+			exp: expected{
+				nodeJS:  `say \"hi\"`,
+				django:  `say "hi"`,
+				compose: `say "hi"`,
+				systemd: `say "hi"`,
+			},
+		},
+		"escaped single quote": {
+			env: `V='it\'s'`,
+			exp: expected{
+				nodeJS:  `it\'s`,
+				django:  `it\'s`,
+				compose: `it's`,
+				systemd: `it\s'`,
+			},
+		},
+		"escaped backslash": {
+			env: `V="C:\\temp"`,
+			exp: expected{
+				nodeJS:  `C:\\temp`,
+				django:  `C:\temp`,
+				compose: `C:\temp`,
+				systemd: `C:\temp`,
+			},
+		},
+		"tab": {
+			env: `V="a\tb"`,
+			exp: expected{
+				nodeJS:  `a\tb`,
+				django:  `atb`,
+				compose: "a\tb",
+				systemd: `a\tb`,
+			},
+		},
+		"carriage return": {
+			env: `V="a\rb"`,
+			exp: expected{
+				nodeJS:  "a\rb",
+				django:  `arb`,
+				compose: "a\rb",
+				systemd: `a\rb`,
+			},
+		},
+		"carriage return and newline": {
+			env: `V="a\r\nb"`,
+			exp: expected{
+				nodeJS:  "a\r\nb",
+				django:  "a\nb",
+				compose: "a\r\nb",
+				systemd: `a\r\nb`,
+			},
+		},
+		"newline": {
+			env: `V="a\nb"`,
+			exp: expected{
+				nodeJS:  "a\nb",
+				django:  "a\nb",
+				compose: "a\nb",
+				systemd: `a\nb`,
+			},
+		},
+		"dollar": {
+			env: `V="\$HOME"`,
+			exp: expected{
+				nodeJS:  `$HOME`,
+				django:  `$HOME`,
+				compose: `$HOME`,
+				systemd: `$HOME`,
+			},
+		},
+		"unicode": {
+			env: `V="\u00e9"`,
+			exp: expected{
+				nodeJS:  `\u00e9`,
+				django:  `u00e9`,
+				compose: `\u00e9`,
+				systemd: `\u00e9`,
+			},
+		},
+		"unquoted": {
+			env: `V=a\\b\tc\n`,
+			exp: expected{
+				nodeJS:  `a\\b\tc\n`,
+				django:  `a\\b\tc\n`,
+				compose: `a\\b\tc\n`,
+				systemd: `a\btcn`,
+			},
+		},
+	}
+
+	for cn, c := range cases {
+		t.Run(cn, func(t *testing.T) {
+			for _, d := range []struct {
+				name    string
+				scanner *dotEnvScanner
+				exp     string
+			}{
+				{"NodeJS", newNodeJSScanner(), c.exp.nodeJS},
+				{"Django", newDjangoScanner(), c.exp.django},
+				{"Compose", newDockerComposeScanner(), c.exp.compose},
+				{"systemd", newSystemdScanner(), c.exp.systemd},
+			} {
+				t.Run(d.name, func(t *testing.T) {
+					d.scanner.lookupEnv = func(string) (string, bool) { return "", false }
+					env, err := dotEnvToEnv(d.scanner, bytes.NewReader([]byte(c.env)))
+					xt.OK(t, err)
+					v, ok := env.Lookup("V")
+					xt.Assert(t, ok)
+					xt.Eq(t, d.exp, v)
+				})
+			}
+		})
+	}
 }
@@ -0,0 +1,220 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// secretMask replaces the value of fields with the secret option.
+const secretMask = "******"
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// dumpVar is a variable with its value as dumped.
+type dumpVar struct {
+	name  string
+	value string
+	naked bool // field is a nil pointer
+}
+
+// Dump returns the variables used by the fields of the struct dest, or the
+// struct dest points to, with their values, one per line. Fields with the
+// secret option, for example, `envVar:"DB_PASSWORD,secret"`, are masked
+// unless they are empty. Fields which are nil pointers are shown as naked
+// variables. This is useful to log the configuration when the application
+// starts.
+//
+// Dump uses the same fields as, for example, OSEnviron, including those of
+// nested and embedded structs. An empty string is returned when dest is
+// not a struct or a non-nil pointer to a struct.
+func Dump(dest any) string {
+	vars := dumpVars(dest)
+
+	lines := make([]string, len(vars))
+	for i, v := range vars {
+		if v.naked {
+			lines[i] = v.name
+		} else {
+			lines[i] = v.name + "=" + v.value
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// LogValuer returns a slog.LogValuer which logs the variables used by the
+// fields of the struct dest as group. Like Dump, secrets are masked.
+//
+//	slog.Info("configuration", "env", envs.LogValuer(cfg))
+func LogValuer(dest any) slog.LogValuer {
+	return logValuer{dest: dest}
+}
+
+type logValuer struct {
+	dest any
+}
+
+func (lv logValuer) LogValue() slog.Value {
+	vars := dumpVars(lv.dest)
+
+	attrs := make([]slog.Attr, len(vars))
+	for i, v := range vars {
+		if v.naked {
+			attrs[i] = slog.Any(v.name, nil)
+		} else {
+			attrs[i] = slog.String(v.name, v.value)
+		}
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// dumpVars returns the variables used by the fields of dest.
+func dumpVars(dest any) []dumpVar {
+	rv := reflect.ValueOf(dest)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var vars []dumpVar
+	dumpStruct(rv, "", &vars)
+	return vars
+}
+
+// dumpStruct adds the variables of the fields of struct value rv to vars,
+// including those of its embedded structs.
+func dumpStruct(rv reflect.Value, prefix string, vars *[]dumpVar) {
+	emb := &embedding{
+		shallowest: map[string]int{},
+		seen:       map[reflect.Type]bool{},
+	}
+	envVarDepths(rv.Type(), prefix, emb)
+
+	dumpFields(rv, prefix, emb, vars)
+}
+
+// dumpFields adds the variables of the fields of struct value rv to vars,
+// skipping shadowed fields like structDecoder does.
+func dumpFields(rv reflect.Value, prefix string, emb *embedding, vars *[]dumpVar) {
+	rt := rv.Type()
+
+	emb.seen[rt] = true
+	defer delete(emb.seen, rt)
+
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+		value := rv.Field(i)
+
+		envVar, options := parseTagEnvVar(rtf)
+		if envVar == "" {
+			if value.Kind() == reflect.Pointer && value.IsNil() {
+				continue
+			}
+
+			p := prefix + rtf.Tag.Get(tagEnvPrefix)
+			switch {
+			case isEmbeddedStruct(rtf):
+				if emb.seen[elemType(rtf.Type)] {
+					continue
+				}
+				emb.depth++
+				dumpFields(reflect.Indirect(value), p, emb, vars)
+				emb.depth--
+			case isNestedStruct(rtf):
+				dumpStruct(reflect.Indirect(value), p, vars)
+			}
+			continue
+		}
+		envVar = prefix + envVar
+
+		if emb.shallowest[envVar] < emb.depth || !value.CanInterface() {
+			continue // shadowed, or not exported
+		}
+
+		v := dumpVar{name: envVar}
+		switch {
+		case value.Kind() == reflect.Pointer && value.IsNil():
+			v.naked = true
+		default:
+			v.value = formatValue(rtf, value)
+			if options[optSecret] && v.value != "" {
+				v.value = secretMask
+			}
+		}
+		*vars = append(*vars, v)
+	}
+}
+
+// formatValue returns value of field as string. Elements of slices are
+// separated using the sep-tag, and pairs of maps using the sep- and
+// kvSep-tags.
+func formatValue(field reflect.StructField, value reflect.Value) string {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	switch v := value.Interface().(type) {
+	case time.Time:
+		layout := field.Tag.Get(tagLayout)
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return v.Format(layout)
+	case fmt.Stringer:
+		return v.String()
+	case encoding.TextMarshaler:
+		if b, err := v.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+
+	if value.CanAddr() && reflect.PointerTo(value.Type()).Implements(stringerType) {
+		return value.Addr().Interface().(fmt.Stringer).String()
+	}
+
+	sep := field.Tag.Get(tagSep)
+	if sep == "" {
+		sep = defaultSep
+	}
+
+	switch value.Kind() {
+	case reflect.Slice:
+		elems := make([]string, value.Len())
+		for i := range elems {
+			elems[i] = formatValue(field, value.Index(i))
+		}
+		return strings.Join(elems, sep)
+	case reflect.Map:
+		kvSep := field.Tag.Get(tagKVSep)
+		if kvSep == "" {
+			kvSep = defaultKVSep
+		}
+
+		pairs := make([]string, 0, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			pairs = append(pairs, formatValue(field, iter.Key())+kvSep+formatValue(field, iter.Value()))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, sep)
+	}
+
+	return fmt.Sprint(value.Interface())
+}
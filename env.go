@@ -0,0 +1,97 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+// Env holds environment variables in the order they were defined, for
+// example, as read from a dot-env file.
+type Env struct {
+	vars  []EnvVar
+	index map[string]int
+}
+
+// EnvVar is an environment variable of Env.
+type EnvVar struct {
+	Name  string
+	Value string // trimmed of surrounding spaces and quotes
+	Naked bool   // variable was defined without value and equal sign
+	Line  int    // line on which the variable is defined; 0 when unknown
+}
+
+// add stores v. When a variable with the same name was already added,
+// it is replaced but keeps its position.
+func (e *Env) add(v EnvVar) {
+	if e.index == nil {
+		e.index = map[string]int{}
+	}
+
+	if i, ok := e.index[v.Name]; ok {
+		e.vars[i] = v
+		return
+	}
+
+	e.index[v.Name] = len(e.vars)
+	e.vars = append(e.vars, v)
+}
+
+// Len returns the number of variables in e.
+func (e *Env) Len() int {
+	return len(e.vars)
+}
+
+// Vars returns a copy of the variables in the order they were defined.
+func (e *Env) Vars() []EnvVar {
+	return append([]EnvVar(nil), e.vars...)
+}
+
+// Names returns the names of the variables in the order they were defined.
+func (e *Env) Names() []string {
+	names := make([]string, len(e.vars))
+	for i, v := range e.vars {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// Get returns the variable with name and whether it is available.
+func (e *Env) Get(name string) (EnvVar, bool) {
+	i, ok := e.index[name]
+	if !ok {
+		return EnvVar{}, false
+	}
+	return e.vars[i], true
+}
+
+// Lookup returns the value of the variable with name and whether it
+// is available. Like os.LookupEnv, naked variables are considered as not
+// available.
+func (e *Env) Lookup(name string) (string, bool) {
+	v, ok := e.Get(name)
+	if !ok || v.Naked {
+		return "", false
+	}
+	return v.Value, true
+}
+
+// Map returns the variables as map. Naked variables are not included.
+func (e *Env) Map() map[string]string {
+	m := make(map[string]string, len(e.vars))
+	for _, v := range e.vars {
+		if !v.Naked {
+			m[v.Name] = v.Value
+		}
+	}
+	return m
+}
+
+// Environ returns the variables as strings in the form "key=value", like
+// os.Environ, which can be used with, for example, exec.Cmd. Naked variables
+// are not included.
+func (e *Env) Environ() []string {
+	environ := make([]string, 0, len(e.vars))
+	for _, v := range e.vars {
+		if !v.Naked {
+			environ = append(environ, v.Name+"="+v.Value)
+		}
+	}
+	return environ
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	t.Run("NodeJS keeps order and lines", func(t *testing.T) {
+		env, err := ParseNodeJSDotEnv(strings.NewReader(`# comment
+ZULU=last?
+ALPHA="  quoted  "
+EMPTY=
+
+BRAVO='multi
+line'
+ZULU=  redefined  
+`))
+		xt.OK(t, err)
+		xt.Eq(t, 4, env.Len())
+		xt.Eq(t, []string{"ZULU", "ALPHA", "EMPTY", "BRAVO"}, env.Names())
+		xt.Eq(t, []EnvVar{
+			{Name: "ZULU", Value: "redefined", Line: 8},
+			{Name: "ALPHA", Value: "  quoted  ", Line: 3},
+			{Name: "EMPTY", Value: "", Line: 4},
+			{Name: "BRAVO", Value: "multi\nline", Line: 6},
+		}, env.Vars())
+	})
+
+	t.Run("Django naked and empty variables", func(t *testing.T) {
+		env, err := ParseDjangoDotEnv(strings.NewReader("NAKED\nEMPTY=\nNAME=alice\n"))
+		xt.OK(t, err)
+
+		v, ok := env.Get("NAKED")
+		xt.Assert(t, ok)
+		xt.Assert(t, v.Naked)
+		xt.Eq(t, 1, v.Line)
+
+		_, ok = env.Lookup("NAKED")
+		xt.Assert(t, !ok, "naked variable is not available using Lookup")
+
+		value, ok := env.Lookup("EMPTY")
+		xt.Assert(t, ok)
+		xt.Eq(t, "", value)
+
+		_, ok = env.Get("NOT_SET")
+		xt.Assert(t, !ok)
+
+		xt.Eq(t, map[string]string{"EMPTY": "", "NAME": "alice"}, env.Map())
+		xt.Eq(t, []string{"EMPTY=", "NAME=alice"}, env.Environ())
+	})
+
+	t.Run("expanded variables", func(t *testing.T) {
+		env, err := ParseNodeJSDotEnv(strings.NewReader("BASE=/srv\nDATA=${BASE}/data\n"))
+		xt.OK(t, err)
+		v, _ := env.Lookup("DATA")
+		xt.Eq(t, "/srv/data", v)
+	})
+
+	t.Run("from file", func(t *testing.T) {
+		env, err := ParseNodeJSDotEnvFromFile("_test_data/js.example.env")
+		xt.OK(t, err)
+		xt.Eq(t, []string{"HOME", "USER", "AVATAR"}, env.Names())
+
+		env, err = ParseDjangoDotEnvFromFile("_test_data/py.env")
+		xt.OK(t, err)
+		v, ok := env.Get("PTR_NUMBER_naked")
+		xt.Assert(t, ok)
+		xt.Assert(t, v.Naked)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := ParseNodeJSDotEnv(strings.NewReader("A=1\nB=\"not closed"))
+		xt.KO(t, err)
+		xt.Eq(t, "line 2: syntax error (missing closing quote)", err.Error())
+
+		_, err = ParseDjangoDotEnvFromFile("_test_data/not_available.env")
+		xt.KO(t, err)
+		xt.Assert(t, strings.Contains(err.Error(), "no such file or directory"))
+	})
+}
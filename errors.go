@@ -2,21 +2,42 @@
 
 package envs
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
 
 type ErrSyntax struct {
-	Line   int
-	EnvVar string
-	Reason string
+	Line     int
+	FilePath string // file in which the variable is defined; set by Loader
+	EnvVar   string
+	Field    string // path of the struct field, for example, Database.Port
+	Reason   string
+	Err      error // error returned by a Decoder or encoding.TextUnmarshaler
 }
 
 func (err *ErrSyntax) Error() string {
+	if err.Line > 0 && err.FilePath != "" {
+		return fmt.Sprintf("%s:%d: syntax error (%s)", err.FilePath, err.Line, err.Reason)
+	}
 	if err.Line > 0 {
 		return fmt.Sprintf("line %d: syntax error (%s)", err.Line, err.Reason)
 	}
 	return fmt.Sprintf("%s: syntax error (%s)", err.EnvVar, err.Reason)
 }
 
+func (err *ErrSyntax) Unwrap() error {
+	return err.Err
+}
+
+// redact removes the reason and the wrapped error since they could reveal
+// the value of a secret.
+func (err *ErrSyntax) redact() {
+	err.Reason = "value of secret not valid"
+	err.Err = nil
+}
+
 type ErrReadingFile struct {
 	FilePath string
 	Err      error
@@ -25,3 +46,77 @@ type ErrReadingFile struct {
 func (err *ErrReadingFile) Error() string {
 	return fmt.Sprintf("error reading %s (%s)", err.FilePath, err.Err)
 }
+
+func (err *ErrReadingFile) Unwrap() error {
+	return err.Err
+}
+
+// ErrMissing is returned when a variable with the required option is not
+// available, or when a variable with the notEmpty option is available
+// but has no value.
+type ErrMissing struct {
+	EnvVar string
+	Field  string // path of the struct field, for example, Database.Host
+	Empty  bool   // variable is available but has no value
+}
+
+func (err *ErrMissing) Error() string {
+	if err.Field == "" {
+		return fmt.Sprintf("%s: variable not defined", err.EnvVar)
+	}
+	if err.Empty {
+		return fmt.Sprintf("%s: variable is empty (field %s)", err.EnvVar, err.Field)
+	}
+	return fmt.Sprintf("%s: required variable missing (field %s)", err.EnvVar, err.Field)
+}
+
+// ErrDecoding is returned when all errors are collected while storing
+// variables in the destination struct, for example, using the AllErrors
+// option. The errors can be retrieved using errors.As.
+type ErrDecoding struct {
+	Errs []error
+}
+
+func (err *ErrDecoding) Error() string {
+	msgs := make([]string, len(err.Errs))
+	for i, e := range err.Errs {
+		if es, ok := e.(*ErrSyntax); ok && es.Field != "" {
+			msgs[i] = fmt.Sprintf("%s (field %s)", e, es.Field)
+		} else {
+			msgs[i] = e.Error()
+		}
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (err *ErrDecoding) Unwrap() []error {
+	return err.Errs
+}
+
+// ErrInvalidDestination is returned when the destination is not a non-nil
+// pointer to a struct, or when part of it cannot be set.
+type ErrInvalidDestination struct {
+	Type   reflect.Type
+	Reason string
+}
+
+func (err *ErrInvalidDestination) Error() string {
+	return fmt.Sprintf("invalid destination %v (%s)", err.Type, err.Reason)
+}
+
+// ErrUnsupportedType is returned when a field with the envVar-tag has a
+// type which is not supported, or when the field is not exported.
+type ErrUnsupportedType struct {
+	EnvVar     string
+	Field      string // path of the struct field, for example, Database.Port
+	Type       reflect.Type
+	Unexported bool // field is not exported and cannot be set
+}
+
+func (err *ErrUnsupportedType) Error() string {
+	if err.Unexported {
+		return fmt.Sprintf("%s: field %s is not exported", err.EnvVar, err.Field)
+	}
+	return fmt.Sprintf("%s: unsupported type '%v' for field %s", err.EnvVar, err.Type, err.Field)
+}
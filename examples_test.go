@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/golistic/envs"
 )
@@ -54,3 +55,24 @@ func ExampleNodeJSDotEnvFromFile() {
 	// HomeDir : /home/alice
 	// Avatar  : 🙂️
 }
+
+func ExampleParseNodeJSDotEnv() {
+	r := strings.NewReader(`# users
+USER=alice
+HOME="/home/${USER}"
+`)
+
+	env, err := envs.ParseNodeJSDotEnv(r)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	for _, v := range env.Vars() {
+		fmt.Printf("line %d: %s=%s\n", v.Line, v.Name, v.Value)
+	}
+
+	// Output:
+	// line 2: USER=alice
+	// line 3: HOME=/home/alice
+}
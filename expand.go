@@ -0,0 +1,488 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// expander expands references to other variables, like `$VAR` or `${VAR}`,
+// within the values parsed by dotEnvScanner following the rules of the
+// NPM package https://www.npmjs.com/package/dotenv-expand:
+//
+//   - `$VAR` and `${VAR}` are replaced with the value of VAR
+//   - `${VAR:-default}` uses default when VAR is not set or empty
+//   - `${VAR-default}` uses default only when VAR is not set
+//   - `\$` results in a literal `$`
+//   - single-quoted values are not expanded
+//
+// Variables in the OS environment take precedence over those in the file,
+// which can be referenced regardless of the order in which they are defined.
+type expander struct {
+	vars      envVarMap
+	lines     map[string]int
+	lookupEnv func(string) (string, bool)
+
+	expanded  map[string]string // expanded raw values, including quotes
+	resolving []string          // variables being expanded; used to detect cycles
+}
+
+func newExpander(vars envVarMap, lines map[string]int) *expander {
+	return &expander{
+		vars:      vars,
+		lines:     lines,
+		lookupEnv: os.LookupEnv,
+		expanded:  map[string]string{},
+	}
+}
+
+// expandAll expands the values of all variables. Variables are handled in
+// the order they were defined so errors are reported consistently.
+func (e *expander) expandAll() error {
+	names := make([]string, 0, len(e.vars))
+	for name := range e.vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return e.lines[names[i]] < e.lines[names[j]]
+	})
+
+	for _, name := range names {
+		if e.vars[name] == nil {
+			continue
+		}
+
+		v, err := e.resolve(name)
+		if err != nil {
+			return err
+		}
+		e.vars[name] = &v
+	}
+
+	return nil
+}
+
+// resolve returns the expanded raw value of variable name found in the file.
+func (e *expander) resolve(name string) (string, error) {
+	if v, ok := e.expanded[name]; ok {
+		return v, nil
+	}
+
+	for i, r := range e.resolving {
+		if r == name {
+			return "", &ErrSyntax{
+				Line:   e.lines[name],
+				EnvVar: name,
+				Reason: "variable expansion cycle " + strings.Join(append(e.resolving[i:], name), " -> "),
+			}
+		}
+	}
+
+	e.resolving = append(e.resolving, name)
+	defer func() { e.resolving = e.resolving[:len(e.resolving)-1] }()
+
+	raw := e.vars[name]
+	if raw == nil {
+		e.expanded[name] = ""
+		return "", nil
+	}
+
+	v, err := e.expandRaw(name, *raw)
+	if err != nil {
+		return "", err
+	}
+
+	e.expanded[name] = v
+	return v, nil
+}
+
+// lookup returns the value of variable name, first looking in the OS
+// environment, then in the file. The value from the file is expanded
+// and stripped of surrounding spaces and quotes.
+func (e *expander) lookup(name string) (string, bool, error) {
+	if v, ok := e.lookupEnv(name); ok {
+		return v, true, nil
+	}
+
+	if _, ok := e.vars[name]; !ok {
+		return "", false, nil
+	}
+
+	v, err := e.resolve(name)
+	if err != nil {
+		return "", false, err
+	}
+
+	return stripQuotes(strings.TrimSpace(v)), true, nil
+}
+
+// expandRaw expands the raw value of variable name which still includes
+// the quotes. Single-quoted values are returned as-is.
+func (e *expander) expandRaw(name, raw string) (string, error) {
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '`') && raw[len(raw)-1] == raw[0] {
+		v, err := e.expandString(name, raw[1:len(raw)-1])
+		if err != nil {
+			return "", err
+		}
+		return raw[:1] + v + raw[:1], nil
+	}
+
+	if strings.HasPrefix(raw, "'") {
+		return raw, nil
+	}
+
+	return e.expandString(name, raw)
+}
+
+// expandString expands all references to variables found in s, which is
+// (part of) the value of variable name.
+func (e *expander) expandString(name, s string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteByte('$')
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := closingBrace(s, i+2)
+			if end == -1 {
+				return "", &ErrSyntax{Line: e.lines[name], EnvVar: name, Reason: "missing closing brace"}
+			}
+
+			v, err := e.expandBraced(name, s[i+2:end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			i = end
+		case s[i] == '$' && i+1 < len(s) && isNameStart(s[i+1]):
+			end := i + 1
+			for end < len(s) && isNameChar(s[end]) {
+				end++
+			}
+
+			v, _, err := e.lookup(s[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			i = end - 1
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+// expandBraced expands the reference found between `${` and `}`, which is
+// a variable name optionally followed by `:-default` or `-default`.
+func (e *expander) expandBraced(name, ref string) (string, error) {
+	end := 0
+	for end < len(ref) && isNameChar(ref[end]) {
+		end++
+	}
+
+	variable, rest := ref[:end], ref[end:]
+	if variable == "" || isDigit(variable[0]) ||
+		!(rest == "" || strings.HasPrefix(rest, ":-") || strings.HasPrefix(rest, "-")) {
+		return "", &ErrSyntax{Line: e.lines[name], EnvVar: name, Reason: "invalid variable expansion ${" + ref + "}"}
+	}
+
+	v, have, err := e.lookup(variable)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case strings.HasPrefix(rest, ":-"):
+		if !have || v == "" {
+			return e.expandString(name, rest[2:])
+		}
+	case strings.HasPrefix(rest, "-"):
+		if !have {
+			return e.expandString(name, rest[1:])
+		}
+	}
+
+	return v, nil
+}
+
+// expandDjango expands references to variables within raw, the value
+// of a variable which still includes the quotes, following the rules of
+// django-dotenv:
+//
+//   - `$VAR` and `${VAR}` are replaced with the value of VAR; names are
+//     letters, digits, and underscores, and braces are optional on both sides
+//   - variables defined earlier in the file take precedence over those in
+//     the OS environment; unknown variables are replaced with an empty string
+//   - `\$VAR` results in a literal `$VAR`
+//   - single-quoted values are not expanded
+func (ds *dotEnvScanner) expandDjango(raw string) string {
+	if strings.HasPrefix(raw, "'") {
+		return raw
+	}
+
+	lookupEnv := ds.lookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == '$':
+			if n, _ := djangoReference(raw[i+1:]); n > 0 {
+				b.WriteString(raw[i+1 : i+1+n])
+				i += n
+			} else {
+				b.WriteByte(raw[i])
+			}
+		case raw[i] == '$':
+			n, name := djangoReference(raw[i:])
+			if n == 0 {
+				b.WriteByte(raw[i])
+				continue
+			}
+
+			if v := ds.vars[name]; v != nil {
+				b.WriteString(stripQuotes(strings.TrimSpace(*v)))
+			} else if v, ok := lookupEnv(name); ok {
+				b.WriteString(v)
+			}
+			i += n - 1
+		default:
+			b.WriteByte(raw[i])
+		}
+	}
+
+	return b.String()
+}
+
+// expandCompose expands references to variables within raw, the value of
+// variable name which still includes the quotes, following the rules of
+// Docker Compose for env_file:
+//
+//   - `$VAR` and `${VAR}` are replaced with the value of VAR
+//   - `${VAR:-default}` and `${VAR-default}` use default when VAR is not set
+//     or empty, respectively not set
+//   - `${VAR:+replacement}` and `${VAR+replacement}` use replacement when
+//     VAR is set and not empty, respectively set
+//   - `${VAR:?error}` and `${VAR?error}` are errors when VAR is not set or
+//     empty, respectively not set
+//   - `$$` results in a literal `$`
+//   - double-quoted values support the escapes `\n`, `\r`, `\t`, `\\`,
+//     `\"`, and `\$`; in single-quoted values, only `\'` is supported
+//   - single-quoted values are not expanded
+//
+// Variables defined earlier in the file take precedence over those in the
+// OS environment. Quoted results are surrounded by single quotes, so they are
+// not processed again when unquoted.
+func (ds *dotEnvScanner) expandCompose(name, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+
+	var v string
+	var err error
+	switch {
+	case len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'':
+		return quoteRaw(strings.ReplaceAll(raw[1:len(raw)-1], `\'`, `'`), true), nil
+	case len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"':
+		v, err = ds.interpolateCompose(name, raw[1:len(raw)-1], true)
+		return quoteRaw(v, true), err
+	default:
+		v, err = ds.interpolateCompose(name, raw, false)
+		return quoteRaw(v, false), err
+	}
+}
+
+// quoteRaw returns v surrounded by single quotes when quoted is true, or
+// when v would otherwise be changed when unquoted and trimmed.
+func quoteRaw(v string, quoted bool) string {
+	if quoted || v != strings.TrimSpace(v) || (v != "" && strings.ContainsRune("\"'`", rune(v[0]))) {
+		return "'" + v + "'"
+	}
+	return v
+}
+
+// interpolateCompose expands all references to variables found in s, which
+// is (part of) the value of variable name. When escapes is true, backslash
+// escapes are processed.
+func (ds *dotEnvScanner) interpolateCompose(name, s string, escapes bool) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escapes && s[i] == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '$':
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteByte('$')
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := closingBrace(s, i+2)
+			if end == -1 {
+				return "", &ErrSyntax{Line: ds.lines[name], EnvVar: name, Reason: "missing closing brace"}
+			}
+
+			v, err := ds.interpolateComposeBraced(name, s[i+2:end], escapes)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			i = end
+		case s[i] == '$' && i+1 < len(s) && isNameStart(s[i+1]):
+			end := i + 1
+			for end < len(s) && isNameChar(s[end]) {
+				end++
+			}
+
+			v, _ := ds.lookupCompose(s[i+1 : end])
+			b.WriteString(v)
+			i = end - 1
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+// interpolateComposeBraced expands the reference found between `${` and `}`,
+// which is a variable name optionally followed by a modifier like `:-default`.
+func (ds *dotEnvScanner) interpolateComposeBraced(name, ref string, escapes bool) (string, error) {
+	end := 0
+	for end < len(ref) && isNameChar(ref[end]) {
+		end++
+	}
+
+	variable, rest := ref[:end], ref[end:]
+	if variable == "" || isDigit(variable[0]) {
+		return "", &ErrSyntax{Line: ds.lines[name], EnvVar: name, Reason: "invalid variable expansion ${" + ref + "}"}
+	}
+
+	v, have := ds.lookupCompose(variable)
+	set := have && v != ""
+
+	modifier, arg := rest, ""
+	if i := strings.IndexAny(rest, "-+?"); i >= 0 && i <= 1 {
+		modifier, arg = rest[:i+1], rest[i+1:]
+	}
+
+	switch modifier {
+	case "":
+		return v, nil
+	case ":-", "-":
+		if (modifier == "-" && !have) || (modifier == ":-" && !set) {
+			return ds.interpolateCompose(name, arg, escapes)
+		}
+		return v, nil
+	case ":+", "+":
+		if (modifier == "+" && have) || (modifier == ":+" && set) {
+			return ds.interpolateCompose(name, arg, escapes)
+		}
+		return "", nil
+	case ":?", "?":
+		if (modifier == "?" && !have) || (modifier == ":?" && !set) {
+			return "", &ErrSyntax{
+				Line:   ds.lines[name],
+				EnvVar: name,
+				Reason: "required variable " + variable + " is missing a value: " + arg,
+			}
+		}
+		return v, nil
+	}
+
+	return "", &ErrSyntax{Line: ds.lines[name], EnvVar: name, Reason: "invalid variable expansion ${" + ref + "}"}
+}
+
+// lookupCompose returns the value of variable name, first looking in the
+// variables defined earlier in the file, then in the OS environment.
+func (ds *dotEnvScanner) lookupCompose(name string) (string, bool) {
+	if v, ok := ds.vars[name]; ok {
+		if v == nil {
+			return "", true
+		}
+		return stripQuotes(strings.TrimSpace(*v)), true
+	}
+
+	return ds.lookupOS(name)
+}
+
+// djangoReference returns the length and the variable name of the
+// reference s starts with, for example, `${VAR}`. The length is 0 when
+// s does not start with a reference.
+func djangoReference(s string) (int, string) {
+	i := 1 // skip $
+	if i < len(s) && s[i] == '{' {
+		i++
+	}
+
+	start := i
+	for i < len(s) && isNameChar(s[i]) {
+		i++
+	}
+	if i == start {
+		return 0, ""
+	}
+	name := s[start:i]
+
+	if i < len(s) && s[i] == '}' {
+		i++
+	}
+
+	return i, name
+}
+
+// closingBrace returns the index of the brace closing the one opened just
+// before start, taking nested `${...}` into account, or -1 when not found.
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// stripQuotes removes matching single, double, or back quotes surrounding s.
+func stripQuotes(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'' || s[0] == '`') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+
+	"github.com/golistic/xgo/xstrings"
+)
+
+// Source provides environment variables to a Loader. Use, for example,
+// OSSource or NodeJSDotEnvSource to create one.
+type Source struct {
+	name     string
+	path     string
+	optional bool
+	read     func(options []Option) (envVarMap, map[string]int, error)
+}
+
+// OSSource returns a Source providing the variables of the operating
+// system's environment.
+func OSSource() Source {
+	return Source{
+		name: sourceOS,
+		read: func([]Option) (envVarMap, map[string]int, error) {
+			return osEnvVarMap(), nil, nil
+		},
+	}
+}
+
+// MapSource returns a Source called name providing the variables found
+// in vars. The variables are copied when loading.
+func MapSource(name string, vars map[string]string) Source {
+	return Source{
+		name: name,
+		read: func([]Option) (envVarMap, map[string]int, error) {
+			src := envVarMap{}
+			for k, v := range vars {
+				src[k] = xstrings.Pointer(v)
+			}
+			return src, nil, nil
+		},
+	}
+}
+
+// NodeJSDotEnvSource returns a Source providing the variables of the dot-env
+// file with path, read using the rules of NodeJSDotEnv().
+func NodeJSDotEnvSource(path string) Source {
+	return Source{
+		name: path,
+		path: path,
+		read: func(options []Option) (envVarMap, map[string]int, error) {
+			return readDotEnvFile(newNodeJSScanner(), path, options)
+		},
+	}
+}
+
+// DjangoDotEnvSource returns a Source providing the variables of the dot-env
+// file with path, read using the rules of DjangoDotEnv().
+func DjangoDotEnvSource(path string) Source {
+	return Source{
+		name: path,
+		path: path,
+		read: func(options []Option) (envVarMap, map[string]int, error) {
+			return readDotEnvFile(newDjangoScanner(), path, options)
+		},
+	}
+}
+
+// DockerComposeDotEnvSource returns a Source providing the variables of the
+// dot-env file with path, read using the rules of DockerComposeDotEnv().
+func DockerComposeDotEnvSource(path string) Source {
+	return Source{
+		name: path,
+		path: path,
+		read: func(options []Option) (envVarMap, map[string]int, error) {
+			return readDotEnvFile(newDockerComposeScanner(), path, options)
+		},
+	}
+}
+
+// SystemdEnvironmentFileSource returns a Source providing the variables of the
+// environment file with path, read using the rules of SystemdEnvironmentFile().
+func SystemdEnvironmentFileSource(path string) Source {
+	return Source{
+		name: path,
+		path: path,
+		read: func(options []Option) (envVarMap, map[string]int, error) {
+			return readDotEnvFile(newSystemdScanner(), path, options)
+		},
+	}
+}
+
+// Optional returns a copy of src which is ignored when its file does
+// not exist. Sources are required by default.
+func (src Source) Optional() Source {
+	src.optional = true
+	return src
+}
+
+// Name returns the name of src: "os" for OSSource, the path for
+// dot-env files, or the name given to MapSource.
+func (src Source) Name() string {
+	return src.name
+}
+
+// Loader combines the variables of multiple sources, for example, the OS
+// environment and several dot-env files, and stores them in a struct.
+// Sources added later take precedence over those added earlier.
+type Loader struct {
+	sources []Source
+}
+
+// NewLoader returns a Loader using sources. Sources added later take
+// precedence over those added earlier.
+func NewLoader(sources ...Source) *Loader {
+	return &Loader{sources: sources}
+}
+
+// Add adds sources to l, taking precedence over the sources already
+// added, and returns l.
+func (l *Loader) Add(sources ...Source) *Loader {
+	l.sources = append(l.sources, sources...)
+	return l
+}
+
+// Load reads the variables of all sources, merges them, and stores the
+// result in the struct dest. When a variable is available in multiple
+// sources, the value of the source added last is used. Naked variables,
+// like `FOO` without equal sign, do not replace a value provided by an
+// earlier source. Default values of the default-tag are only used when no
+// source provides the variable.
+//
+// Returns ErrReadingFile when the file of a required source cannot be
+// read. Syntax errors of dot-env files, including those of values stored
+// in dest, report the file and the line.
+func (l *Loader) Load(dest any, options ...Option) error {
+	src := envVarMap{}
+	origins := map[string]origin{}
+
+	for _, s := range l.sources {
+		vars, lines, err := s.read(options)
+		if err != nil {
+			if s.optional && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if e, ok := err.(*ErrSyntax); ok {
+				e.FilePath = s.path
+			}
+			return err
+		}
+
+		for name, value := range vars {
+			if _, ok := src[name]; ok && value == nil {
+				continue // naked variables do not replace values of earlier sources
+			}
+			src[name] = value
+			origins[name] = origin{source: s.name, path: s.path, line: lines[name]}
+		}
+	}
+
+	err := reflectMapToStruct(src, dest, append(slices.Clip(options), withOrigin(func(name string) origin {
+		return origins[name]
+	}))...)
+	eachErrSyntax(err, func(e *ErrSyntax) {
+		if o, ok := origins[e.EnvVar]; ok {
+			e.FilePath = o.path
+			e.Line = o.line
+		}
+	})
+
+	return err
+}
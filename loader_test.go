@@ -0,0 +1,117 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+)
+
+func TestLoader_Load(t *testing.T) {
+	type appEnv struct {
+		Host     string `envVar:"ENVS_LOADER_HOST" default:"localhost"`
+		Port     int    `envVar:"ENVS_LOADER_PORT" default:"8080"`
+		Mode     string `envVar:"ENVS_LOADER_MODE"`
+		User     string `envVar:"ENVS_LOADER_USER"`
+		LogLevel string `envVar:"ENVS_LOADER_LOG_LEVEL" default:"info"`
+	}
+
+	dotEnv := writeDotEnv(t, ".env",
+		"ENVS_LOADER_HOST=example.com\nENVS_LOADER_PORT=80\nENVS_LOADER_MODE=production\n")
+	dotEnvLocal := writeDotEnv(t, ".env.local", "ENVS_LOADER_PORT=8000\nENVS_LOADER_USER='alice'\n")
+
+	t.Run("later sources take precedence", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOADER_HOST", "ENVS_LOADER_PORT", "ENVS_LOADER_MODE",
+			"ENVS_LOADER_USER", "ENVS_LOADER_LOG_LEVEL")
+		t.Setenv("ENVS_LOADER_MODE", "development")
+
+		dest := &appEnv{}
+		l := NewLoader(
+			MapSource("defaults", map[string]string{"ENVS_LOADER_HOST": "internal"}),
+			NodeJSDotEnvSource(dotEnv),
+			DjangoDotEnvSource(dotEnvLocal),
+			OSSource(),
+		)
+		xt.OK(t, l.Load(dest))
+		xt.Eq(t, appEnv{
+			Host:     "example.com",
+			Port:     8000,
+			Mode:     "development",
+			User:     "alice",
+			LogLevel: "info",
+		}, *dest)
+	})
+
+	t.Run("naked variables do not replace earlier values", func(t *testing.T) {
+		unsetEnv(t, "ENVS_LOADER_USER", "ENVS_LOADER_MODE")
+		naked := writeDotEnv(t, ".env.naked", "ENVS_LOADER_USER\nENVS_LOADER_MODE\n")
+
+		dest := &struct {
+			User string  `envVar:"ENVS_LOADER_USER"`
+			Mode *string `envVar:"ENVS_LOADER_MODE"`
+		}{}
+		l := NewLoader(DjangoDotEnvSource(dotEnvLocal), DjangoDotEnvSource(naked))
+		xt.OK(t, l.Load(dest))
+		xt.Eq(t, "alice", dest.User)
+		xt.Assert(t, dest.Mode == nil)
+	})
+
+	t.Run("optional file missing", func(t *testing.T) {
+		dest := &appEnv{}
+		l := NewLoader(NodeJSDotEnvSource(dotEnv)).
+			Add(NodeJSDotEnvSource("_test_data/not_available.env").Optional())
+		xt.OK(t, l.Load(dest))
+		xt.Eq(t, 80, dest.Port)
+	})
+
+	t.Run("required file missing", func(t *testing.T) {
+		dest := &appEnv{}
+		l := NewLoader(NodeJSDotEnvSource(dotEnv), NodeJSDotEnvSource("_test_data/not_available.env"))
+		err := l.Load(dest)
+		xt.KO(t, err)
+		var errReading *ErrReadingFile
+		xt.Assert(t, errors.As(err, &errReading))
+		xt.Eq(t, "_test_data/not_available.env", errReading.FilePath)
+	})
+
+	t.Run("syntax errors report file and line", func(t *testing.T) {
+		broken := writeDotEnv(t, ".env.broken", "# port\n\nENVS_LOADER_PORT=eighty\n")
+
+		dest := &appEnv{}
+		err := NewLoader(NodeJSDotEnvSource(dotEnv), NodeJSDotEnvSource(broken)).Load(dest)
+		xt.KO(t, err)
+		xt.Eq(t, broken+":3: syntax error (number not parsable)", err.Error())
+
+		unclosed := writeDotEnv(t, ".env.unclosed", "ENVS_LOADER_USER='alice")
+		err = NewLoader(DjangoDotEnvSource(unclosed)).Load(dest)
+		xt.KO(t, err)
+		xt.Eq(t, unclosed+":1: syntax error (missing closing quote)", err.Error())
+	})
+
+	t.Run("options are used when reading files", func(t *testing.T) {
+		exported := writeDotEnv(t, ".env.exported", "export ENVS_LOADER_USER=alice\n")
+
+		dest := &appEnv{}
+		xt.OK(t, NewLoader(DjangoDotEnvSource(exported)).Load(dest))
+		xt.Eq(t, "alice", dest.User)
+
+		err := NewLoader(DjangoDotEnvSource(exported)).Load(dest, AllowExport(false))
+		xt.KO(t, err)
+		xt.Eq(t, exported+":1: syntax error (export keyword not allowed)", err.Error())
+	})
+
+	t.Run("syntax error of map source", func(t *testing.T) {
+		dest := &appEnv{}
+		err := NewLoader(MapSource("test", map[string]string{"ENVS_LOADER_PORT": "eighty"})).Load(dest)
+		xt.KO(t, err)
+		xt.Eq(t, "ENVS_LOADER_PORT: syntax error (number not parsable)", err.Error())
+	})
+
+	t.Run("source names", func(t *testing.T) {
+		xt.Eq(t, "os", OSSource().Name())
+		xt.Eq(t, "test", MapSource("test", nil).Name())
+		xt.Eq(t, ".env", NodeJSDotEnvSource(".env").Optional().Name())
+	})
+}
@@ -3,7 +3,11 @@
 package envs
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
@@ -11,8 +15,35 @@ import (
 )
 
 const (
-	tagEnvVar  = "envVar"
-	tagDefault = "default"
+	tagEnvVar    = "envVar"
+	tagDefault   = "default"
+	tagSep       = "sep"
+	tagKVSep     = "kvSep"
+	tagEnvPrefix = "envPrefix"
+	tagLayout    = "layout"
+)
+
+const (
+	defaultSep   = ","
+	defaultKVSep = ":"
+)
+
+// Options which can be added to the envVar-tag, separated by commas,
+// for example, `envVar:"RATIO,extendedFloat"`.
+const (
+	// optExtendedFloat allows exponents, hexadecimal notation, Inf and NaN
+	// for floating point and complex numbers.
+	optExtendedFloat = "extendedFloat"
+
+	// optRequired makes it an error when the variable is not available.
+	optRequired = "required"
+
+	// optNotEmpty makes it an error when the variable is available but
+	// has no value, for example, naked variables.
+	optNotEmpty = "notEmpty"
+
+	// optSecret masks the value when dumped, and keeps it out of errors.
+	optSecret = "secret"
 )
 
 var trues = map[string]struct{}{
@@ -38,60 +69,475 @@ var falses = map[string]struct{}{
 //
 // If src does not contain the field's envVar-tag, it will use
 // the value of the default-tag. If not, the empty value is considered.
-func reflectMapToStruct(src envVarMap, dest any) error {
-	rv := reflect.Indirect(reflect.ValueOf(dest))
-	rt := rv.Type()
-	if rt.Kind() != reflect.Struct {
-		panic(fmt.Sprintf("dest must be non-nil struct (was %s)", rt.String()))
+//
+// Fields which are structs, or pointers to structs, and which do not have
+// the envVar-tag are handled recursively. The value of their envPrefix-tag
+// is prepended to the variable names of the nested fields.
+//
+// Fields of embedded structs are handled as if they were fields of the
+// embedding struct. When fields use the same variable, the shallowest field
+// wins, like Go's rules for promoted fields. Shadowed fields are not set.
+// Fields using the same variable at the same depth are all set.
+//
+// By default, the first error is returned. When the allErrors option is
+// set, all fields are processed and the errors are returned as ErrDecoding.
+func reflectMapToStruct(src envVarMap, dest any, options ...Option) error {
+	rv, err := destinationStruct(dest)
+	if err != nil {
+		return err
+	}
+
+	d := &structDecoder{src: src}
+	for _, o := range options {
+		o(&d.config)
+	}
+
+	if d.config.provenance != nil {
+		d.config.provenance.Fields = nil
+	}
+
+	if err := d.decodeStruct(rv, "", ""); err != nil {
+		return err
+	}
+
+	if len(d.errs) > 0 {
+		return &ErrDecoding{Errs: d.errs}
+	}
+	return nil
+}
+
+// destinationStruct returns the struct value dest points to. It returns
+// ErrInvalidDestination when dest is not a non-nil pointer to a struct.
+func destinationStruct(dest any) (reflect.Value, error) {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return reflect.Value{}, &ErrInvalidDestination{
+			Type:   reflect.TypeOf(dest),
+			Reason: "must be a non-nil pointer to a struct",
+		}
+	}
+
+	if rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, &ErrInvalidDestination{
+			Type:   rv.Type(),
+			Reason: "must point to a struct",
+		}
 	}
 
+	return rv.Elem(), nil
+}
+
+// structDecoder sets the fields of structs using the variables in src.
+type structDecoder struct {
+	src      envVarMap
+	config   decodeConfig
+	errs     []error               // errors collected when config.allErrors is set
+	decoding map[nestedStruct]bool // structs being decoded, preventing endless recursion
+}
+
+// nestedStruct is a struct type decoded using prefix.
+type nestedStruct struct {
+	rt     reflect.Type
+	prefix string
+}
+
+// collect stores err and returns nil when all errors are collected,
+// otherwise err is returned.
+func (d *structDecoder) collect(err error) error {
+	if !d.config.allErrors {
+		return err
+	}
+
+	d.errs = append(d.errs, err)
+	return nil
+}
+
+// embedding holds the state while handling the fields of a struct and
+// those of its embedded structs.
+type embedding struct {
+	depth      int                   // depth of the embedded struct (0 is the embedding struct)
+	shallowest map[string]int        // shallowest depth for each variable
+	seen       map[reflect.Type]bool // embedded struct types being handled
+}
+
+// decodeStruct sets the fields of the struct value rv, including those of
+// its embedded structs. The prefix is prepended to each variable name, and
+// path, when not empty, is the path of rv within the destination struct.
+func (d *structDecoder) decodeStruct(rv reflect.Value, prefix, path string) error {
+	key := nestedStruct{rt: rv.Type(), prefix: prefix}
+	if d.decoding == nil {
+		d.decoding = map[nestedStruct]bool{}
+	}
+	d.decoding[key] = true
+	defer delete(d.decoding, key)
+
+	emb := &embedding{
+		shallowest: map[string]int{},
+		seen:       map[reflect.Type]bool{},
+	}
+	envVarDepths(rv.Type(), prefix, emb)
+
+	return d.decodeFields(rv, prefix, path, emb)
+}
+
+// decodeFields sets the fields of the struct value rv.
+func (d *structDecoder) decodeFields(rv reflect.Value, prefix, path string, emb *embedding) error {
+	rt := rv.Type()
+
+	emb.seen[rt] = true
+	defer delete(emb.seen, rt)
+
 	for i := 0; i < rt.NumField(); i++ {
 		rtf := rt.Field(i)
+		fieldPath := joinFieldPath(path, rtf.Name)
 
-		envVar := rtf.Tag.Get(tagEnvVar)
+		envVar, options := parseTagEnvVar(rtf)
 		if envVar == "" {
+			var err error
+			switch {
+			case isEmbeddedStruct(rtf):
+				err = d.decodeEmbedded(rv.Field(i), prefix+rtf.Tag.Get(tagEnvPrefix), fieldPath, emb)
+			case isNestedStruct(rtf):
+				err = d.decodeNested(rv.Field(i), prefix+rtf.Tag.Get(tagEnvPrefix), fieldPath)
+			}
+			if err != nil {
+				return err
+			}
 			continue
 		}
+		envVar = prefix + envVar
 
-		envVarValue, have := src[envVar]
-		d := rtf.Tag.Get(tagDefault)
-		if !have && d != "" {
-			envVarValue = &d
-		}
-
-		if envVarValue != nil {
-			*envVarValue = strings.TrimSpace(*envVarValue)
+		if emb.shallowest[envVar] < emb.depth {
+			continue // shadowed by a field of an embedding struct
 		}
 
-		switch t := rv.Field(i).Interface().(type) {
-		case time.Duration, *time.Duration:
-			if err := handleTimeDuration(envVar, rtf, rv.Field(i), envVarValue); err != nil {
+		if err := d.decodeField(rtf, rv.Field(i), envVar, options, fieldPath); err != nil {
+			if err = d.collect(err); err != nil {
 				return err
 			}
-		case string, *string:
-			if err := handleString(envVar, rtf, rv.Field(i), envVarValue); err != nil {
-				return err
+		}
+	}
+
+	return nil
+}
+
+// decodeField sets value of field using variable envVar.
+func (d *structDecoder) decodeField(field reflect.StructField, value reflect.Value,
+	envVar string, options map[string]bool, fieldPath string) error {
+
+	envVarValue, have := d.src[envVar]
+
+	switch {
+	case !have && options[optRequired]:
+		return &ErrMissing{EnvVar: envVar, Field: fieldPath}
+	case have && options[optNotEmpty] && isEmptyValue(envVarValue):
+		return &ErrMissing{EnvVar: envVar, Field: fieldPath, Empty: true}
+	}
+
+	def := field.Tag.Get(tagDefault)
+	if !have && def != "" {
+		envVarValue = &def
+	}
+
+	if envVarValue != nil {
+		*envVarValue = strings.TrimSpace(*envVarValue)
+	}
+
+	if !field.IsExported() {
+		return &ErrUnsupportedType{EnvVar: envVar, Field: fieldPath, Type: field.Type, Unexported: true}
+	}
+
+	if err := setValue(envVar, field, value, envVarValue); err != nil {
+		var errSyntax *ErrSyntax
+		var errUnsupported *ErrUnsupportedType
+		switch {
+		case errors.As(err, &errSyntax):
+			errSyntax.Field = fieldPath
+			if options[optSecret] {
+				errSyntax.redact()
 			}
-		case bool, *bool:
-			if err := handleBoolean(envVar, rtf, rv.Field(i), envVarValue); err != nil {
-				return err
+		case errors.As(err, &errUnsupported):
+			errUnsupported.Field = fieldPath
+		}
+		return err
+	}
+
+	if d.config.provenance != nil {
+		fp := FieldProvenance{Field: fieldPath, EnvVar: envVar}
+		switch {
+		case have && d.config.origin != nil:
+			o := d.config.origin(envVar)
+			fp.Source, fp.FilePath, fp.Line = o.source, o.path, o.line
+		case !have && def != "":
+			fp.Source = sourceDefault
+		}
+		d.config.provenance.Fields = append(d.config.provenance.Fields, fp)
+	}
+
+	return nil
+}
+
+// decodeNested sets the fields of the nested struct value.
+func (d *structDecoder) decodeNested(value reflect.Value, prefix, path string) error {
+	if value.Kind() != reflect.Pointer {
+		return d.decodeStruct(value, prefix, path)
+	}
+
+	return d.decodeStructPointer(value, prefix, func(v reflect.Value) error {
+		return d.decodeStruct(v, prefix, path)
+	})
+}
+
+// decodeEmbedded sets the fields of the embedded struct value. Embedded
+// struct types which are already being handled are skipped.
+func (d *structDecoder) decodeEmbedded(value reflect.Value, prefix, path string, emb *embedding) error {
+	if emb.seen[elemType(value.Type())] {
+		return nil
+	}
+
+	decode := func(v reflect.Value) error {
+		emb.depth++
+		defer func() { emb.depth-- }()
+		return d.decodeFields(v, prefix, path, emb)
+	}
+
+	if value.Kind() != reflect.Pointer {
+		return decode(value)
+	}
+
+	return d.decodeStructPointer(value, prefix, decode)
+}
+
+// decodeStructPointer allocates a new struct for the pointer value, but only
+// when at least one of its variables is available; otherwise value is set
+// to nil. The new struct is passed to decode. Pointers to a struct which is
+// already being decoded using the same prefix, like a linked list without
+// envPrefix-tag, are set to nil since they would never stop recursing.
+//
+// It returns ErrInvalidDestination when value is an embedded pointer to an
+// unexported struct type which needs to be allocated.
+func (d *structDecoder) decodeStructPointer(value reflect.Value, prefix string,
+	decode func(reflect.Value) error) error {
+
+	rt := value.Type().Elem()
+	if d.decoding[nestedStruct{rt: rt, prefix: prefix}] || !structHasVar(rt, prefix, d.src.has) {
+		if value.CanSet() {
+			value.Set(reflect.Zero(value.Type()))
+		}
+		return nil
+	}
+
+	if !value.CanSet() {
+		return &ErrInvalidDestination{
+			Type:   value.Type(),
+			Reason: "cannot set embedded pointer to unexported struct type",
+		}
+	}
+
+	p := reflect.New(rt)
+	if err := decode(p.Elem()); err != nil {
+		return err
+	}
+	value.Set(p)
+	return nil
+}
+
+// joinFieldPath returns the path of the field with name within the struct
+// which has path, for example, "Database.Host".
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// isEmptyValue returns whether s is nil, empty, or consists only of
+// spaces or an empty pair of quotes.
+func isEmptyValue(s *string) bool {
+	if s == nil {
+		return true
+	}
+
+	switch v := strings.TrimSpace(*s); v {
+	case "", `""`, "''", "``":
+		return true
+	}
+	return false
+}
+
+// envVarDepths stores the shallowest depth at which each variable is used
+// by the fields of struct type rt and those of its embedded structs.
+func envVarDepths(rt reflect.Type, prefix string, emb *embedding) {
+	if emb.seen[rt] {
+		return
+	}
+	emb.seen[rt] = true
+	defer delete(emb.seen, rt)
+
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+
+		envVar, _ := parseTagEnvVar(rtf)
+		if envVar == "" {
+			if isEmbeddedStruct(rtf) {
+				emb.depth++
+				envVarDepths(elemType(rtf.Type), prefix+rtf.Tag.Get(tagEnvPrefix), emb)
+				emb.depth--
 			}
-		case int, int8, int16, int32, int64, *int, *int8, *int16, *int32, *int64:
-			err := handleNumeric(envVar, rtf, rv.Field(i), envVarValue)
-			if err != nil {
-				return err
+			continue
+		}
+
+		if d, ok := emb.shallowest[prefix+envVar]; !ok || emb.depth < d {
+			emb.shallowest[prefix+envVar] = emb.depth
+		}
+	}
+}
+
+// isNestedStruct returns whether field is an exported struct, or pointer
+// to a struct, which fields should be handled recursively.
+func isNestedStruct(field reflect.StructField) bool {
+	return !field.Anonymous && field.IsExported() && isEnvStruct(field.Type)
+}
+
+// isEmbeddedStruct returns whether field is an embedded struct, or pointer
+// to a struct, which fields should be handled as if they were fields of
+// the embedding struct.
+func isEmbeddedStruct(field reflect.StructField) bool {
+	return field.Anonymous && isEnvStruct(field.Type)
+}
+
+// isEnvStruct returns whether rt is a struct, or pointer to a struct, with
+// at least one field, possibly nested, with the envVar-tag.
+func isEnvStruct(rt reflect.Type) bool {
+	rt = elemType(rt)
+	return rt.Kind() == reflect.Struct && structHasVar(rt, "", func(string) bool { return true })
+}
+
+// structHasVar returns whether has returns true for at least one of the
+// variables of the fields of struct type rt, including those of nested
+// and embedded structs.
+func structHasVar(rt reflect.Type, prefix string, has func(name string) bool) bool {
+	return structHasVarSeen(rt, prefix, has, map[reflect.Type]bool{})
+}
+
+// structHasVarSeen is like structHasVar but does not go into struct types
+// which have already been seen, preventing endless recursion for types
+// referencing themselves.
+func structHasVarSeen(rt reflect.Type, prefix string, has func(name string) bool,
+	seen map[reflect.Type]bool) bool {
+
+	if seen[rt] {
+		return false
+	}
+	seen[rt] = true
+	defer delete(seen, rt)
+
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+
+		envVar, _ := parseTagEnvVar(rtf)
+		if envVar == "" {
+			ft := elemType(rtf.Type)
+			if ft.Kind() == reflect.Struct && (rtf.Anonymous || rtf.IsExported()) &&
+				structHasVarSeen(ft, prefix+rtf.Tag.Get(tagEnvPrefix), has, seen) {
+				return true
 			}
-		default:
-			panic(fmt.Sprintf("unsupported type '%T' for field %s", t, rtf.Name))
+			continue
+		}
+
+		if has(prefix + envVar) {
+			return true
 		}
 	}
 
-	return nil
+	return false
+}
+
+// setValue sets value using s based on the type of value. The value is
+// either the struct field itself or, for example, an element of a slice
+// which is stored in field.
+//
+// Registered parsers are used first, then the types supported by this
+// package, and finally types implementing Decoder or
+// encoding.TextUnmarshaler.
+func setValue(name string, field reflect.StructField, value reflect.Value, s *string) error {
+	if ok, err := handleParser(name, value, s); ok {
+		return err
+	}
+
+	switch value.Interface().(type) {
+	case time.Duration, *time.Duration:
+		return handleTimeDuration(name, field, value, s)
+	case string, *string:
+		return handleString(name, field, value, s)
+	case bool, *bool:
+		return handleBoolean(name, field, value, s)
+	case int, int8, int16, int32, int64, *int, *int8, *int16, *int32, *int64:
+		return handleNumeric(name, field, value, s)
+	case float32, float64, *float32, *float64:
+		return handleFloat(name, field, value, s)
+	case complex64, complex128, *complex64, *complex128:
+		return handleComplex(name, field, value, s)
+	case uint, uint8, uint16, uint32, uint64, uintptr,
+		*uint, *uint8, *uint16, *uint32, *uint64, *uintptr:
+		return handleUnsigned(name, field, value, s)
+	case time.Time, *time.Time:
+		return handleTime(name, field, value, s)
+	case *time.Location:
+		return handleLocation(name, value, s)
+	case url.URL, *url.URL:
+		return handleStdType(name, value, s, "not a valid URL", parseURL)
+	case net.IP, *net.IP:
+		return handleStdType(name, value, s, "not a valid IP address", parseIP)
+	case net.IPNet, *net.IPNet:
+		return handleStdType(name, value, s, "not a valid IP network in CIDR notation", parseIPNet)
+	case netip.Addr, *netip.Addr:
+		return handleStdType(name, value, s, "not a valid IP address", netip.ParseAddr)
+	case netip.AddrPort, *netip.AddrPort:
+		return handleStdType(name, value, s, "not a valid IP address and port", netip.ParseAddrPort)
+	case netip.Prefix, *netip.Prefix:
+		return handleStdType(name, value, s, "not a valid IP prefix", netip.ParsePrefix)
+	default:
+		if ok, err := handleDecoder(name, value, s); ok {
+			return err
+		}
+
+		switch elemType(value.Type()).Kind() {
+		case reflect.Slice:
+			return handleSlice(name, field, value, s)
+		case reflect.Map:
+			return handleMap(name, field, value, s)
+		}
+		return &ErrUnsupportedType{EnvVar: name, Field: field.Name, Type: value.Type()}
+	}
+}
+
+// parseTagEnvVar returns the name of the environment variable and the
+// options found in the envVar-tag of field.
+func parseTagEnvVar(field reflect.StructField) (string, map[string]bool) {
+	name, rest, _ := strings.Cut(field.Tag.Get(tagEnvVar), ",")
+
+	options := map[string]bool{}
+	for rest != "" {
+		var opt string
+		opt, rest, _ = strings.Cut(rest, ",")
+		if opt = strings.TrimSpace(opt); opt != "" {
+			options[opt] = true
+		}
+	}
+
+	return strings.TrimSpace(name), options
+}
+
+// hasTagOption returns whether the envVar-tag of field has option opt.
+func hasTagOption(field reflect.StructField, opt string) bool {
+	_, options := parseTagEnvVar(field)
+	return options[opt]
 }
 
 func handleString(name string, field reflect.StructField, fieldValue reflect.Value, value *string) error {
 	if value == nil {
-		if field.Type.Kind() == reflect.Pointer {
+		if fieldValue.Kind() == reflect.Pointer {
 			var v *string
 			fieldValue.Set(reflect.ValueOf(v))
 		} else {
@@ -99,13 +545,28 @@ func handleString(name string, field reflect.StructField, fieldValue reflect.Val
 		}
 		return nil
 	}
-	v := *value
 
+	v, err := unquote(name, *value)
+	if err != nil {
+		return err
+	}
+
+	if fieldValue.Kind() == reflect.Pointer {
+		fieldValue.Set(reflect.ValueOf(&v))
+	} else {
+		fieldValue.SetString(v)
+	}
+	return nil
+}
+
+// unquote removes the single, double, or back quotes surrounding v. It is
+// a syntax error when the closing quote is missing.
+func unquote(name string, v string) (string, error) {
 	if v != "" {
 		switch v[0] {
 		case '"', '`', '\'':
-			if v[0] != v[len(v)-1] {
-				return &ErrSyntax{
+			if len(v) < 2 || v[0] != v[len(v)-1] {
+				return "", &ErrSyntax{
 					EnvVar: name,
 					Reason: "missing closing quote",
 				}
@@ -114,17 +575,12 @@ func handleString(name string, field reflect.StructField, fieldValue reflect.Val
 		}
 	}
 
-	if field.Type.Kind() == reflect.Pointer {
-		fieldValue.Set(reflect.ValueOf(v))
-	} else {
-		fieldValue.SetString(v)
-	}
-	return nil
+	return v, nil
 }
 
 // handleTimeDuration takes struct field and its fieldValue and parse the value
 func handleTimeDuration(name string, field reflect.StructField, fieldValue reflect.Value, value *string) error {
-	if field.Type.Kind() == reflect.Pointer {
+	if fieldValue.Kind() == reflect.Pointer {
 		if value == nil {
 			var v *time.Duration
 			fieldValue.Set(reflect.ValueOf(v))
@@ -134,7 +590,7 @@ func handleTimeDuration(name string, field reflect.StructField, fieldValue refle
 
 	if value == nil || *value == "" {
 		v := time.Duration(0)
-		if field.Type.Kind() == reflect.Pointer {
+		if fieldValue.Kind() == reflect.Pointer {
 			fieldValue.Set(reflect.ValueOf(&v))
 		} else {
 			fieldValue.Set(reflect.ValueOf(v))
@@ -150,43 +606,407 @@ func handleTimeDuration(name string, field reflect.StructField, fieldValue refle
 		}
 	}
 
-	fieldValue.Set(reflect.ValueOf(res))
+	if fieldValue.Kind() == reflect.Pointer {
+		fieldValue.Set(reflect.ValueOf(&res))
+	} else {
+		fieldValue.Set(reflect.ValueOf(res))
+	}
 	return nil
 }
 
+// handleNumeric parses s as signed integer making sure it fits the bit
+// size of the field's type.
 func handleNumeric(name string, field reflect.StructField, value reflect.Value, s *string) error {
-	if field.Type.Kind() == reflect.Pointer {
+	if value.Kind() == reflect.Pointer {
 		if s == nil {
 			value.Set(reflect.Zero(value.Type()))
 			return nil
 		}
 	}
 
+	rt := elemType(value.Type())
+
 	var n int64
 
-	if s == nil || *s == "" {
-		n = 0
-	} else {
+	if s != nil && *s != "" {
 		var err error
-		n, err = strconv.ParseInt(*s, 10, 64)
+		n, err = strconv.ParseInt(*s, 10, rt.Bits())
 		if err != nil {
+			return numericSyntaxError(name, rt, err)
+		}
+	}
+
+	if value.Kind() == reflect.Pointer {
+		p := reflect.New(rt)
+		p.Elem().SetInt(n)
+		value.Set(p)
+	} else {
+		value.SetInt(n)
+	}
+	return nil
+}
+
+// handleSlice splits s using the separator found in the sep-tag of field,
+// or a comma when not available. Each element is trimmed of surrounding
+// spaces and set using the same rules as for non-slice fields. Separators
+// within quoted elements are ignored.
+// When s is nil, the slice is set to nil; when s is empty, the slice is
+// empty but not nil.
+func handleSlice(name string, field reflect.StructField, value reflect.Value, s *string) error {
+	if s == nil {
+		value.Set(reflect.Zero(value.Type()))
+		return nil
+	}
+
+	rt := elemType(value.Type())
+
+	sep := field.Tag.Get(tagSep)
+	if sep == "" {
+		sep = defaultSep
+	}
+
+	elements, err := splitQuoted(unquoteWhole(*s), sep)
+	if err != nil {
+		return &ErrSyntax{EnvVar: name, Reason: err.Error()}
+	}
+
+	slice := reflect.MakeSlice(rt, len(elements), len(elements))
+	for i, e := range elements {
+		e = strings.TrimSpace(e)
+		if err := setValue(name, field, slice.Index(i), &e); err != nil {
+			var errSyntax *ErrSyntax
+			if errors.As(err, &errSyntax) {
+				errSyntax.Reason = fmt.Sprintf("element %d: %s", i, errSyntax.Reason)
+			}
+			return err
+		}
+	}
+
+	if value.Kind() == reflect.Pointer {
+		p := reflect.New(rt)
+		p.Elem().Set(slice)
+		value.Set(p)
+	} else {
+		value.Set(slice)
+	}
+	return nil
+}
+
+// handleMap splits s in pairs using the separator found in the sep-tag of
+// field, or a comma when not available. Each pair is split in key and value
+// using the separator found in the kvSep-tag, or a colon when not available.
+// Keys and values are trimmed of surrounding spaces and set using the same
+// rules as for non-map fields. Like with slices, separators within quotes
+// are ignored.
+// When s is nil, the map is set to nil; when s is empty, the map is empty
+// but not nil.
+func handleMap(name string, field reflect.StructField, value reflect.Value, s *string) error {
+	if s == nil {
+		value.Set(reflect.Zero(value.Type()))
+		return nil
+	}
+
+	rt := elemType(value.Type())
+
+	sep := field.Tag.Get(tagSep)
+	if sep == "" {
+		sep = defaultSep
+	}
+
+	kvSep := field.Tag.Get(tagKVSep)
+	if kvSep == "" {
+		kvSep = defaultKVSep
+	}
+
+	pairs, err := splitQuoted(unquoteWhole(*s), sep, kvSep)
+	if err != nil {
+		return &ErrSyntax{EnvVar: name, Reason: err.Error()}
+	}
+
+	m := reflect.MakeMapWithSize(rt, len(pairs))
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+
+		kv, err := splitQuoted(pair, kvSep)
+		if err != nil {
+			return &ErrSyntax{EnvVar: name, Reason: fmt.Sprintf("pair %q: %s", pair, err)}
+		}
+		if len(kv) < 2 {
 			return &ErrSyntax{
 				EnvVar: name,
-				Reason: "number not parsable",
+				Reason: fmt.Sprintf("pair %q: missing key/value separator", pair),
 			}
 		}
+
+		k := strings.TrimSpace(kv[0])
+		v := strings.TrimSpace(strings.Join(kv[1:], kvSep))
+
+		key := reflect.New(rt.Key()).Elem()
+		if err := setValue(name, field, key, &k); err != nil {
+			return pairSyntaxError(pair, err)
+		}
+
+		elem := reflect.New(rt.Elem()).Elem()
+		if err := setValue(name, field, elem, &v); err != nil {
+			return pairSyntaxError(pair, err)
+		}
+
+		m.SetMapIndex(key, elem)
 	}
 
 	if value.Kind() == reflect.Pointer {
-		value.Set(reflect.ValueOf(&n))
+		p := reflect.New(rt)
+		p.Elem().Set(m)
+		value.Set(p)
 	} else {
-		value.SetInt(n)
+		value.Set(m)
 	}
 	return nil
 }
 
+// pairSyntaxError adds the offending pair to the reason of err when it is
+// an ErrSyntax.
+func pairSyntaxError(pair string, err error) error {
+	var errSyntax *ErrSyntax
+	if errors.As(err, &errSyntax) {
+		errSyntax.Reason = fmt.Sprintf("pair %q: %s", pair, errSyntax.Reason)
+	}
+	return err
+}
+
+// unquoteWhole removes the single, double, or back quotes surrounding s, but
+// only when they wrap all of s; quoted elements like `"a","b"` are kept.
+// For example, dot-env files typically quote the complete value.
+func unquoteWhole(s string) string {
+	t := strings.TrimSpace(s)
+	if len(t) < 2 || (t[0] != '"' && t[0] != '\'' && t[0] != '`') {
+		return s
+	}
+
+	if strings.IndexByte(t[1:], t[0]) != len(t)-2 {
+		return s
+	}
+	return t[1 : len(t)-1]
+}
+
+// splitQuoted splits s around each instance of sep, except when sep is
+// found within single, double, or back quotes. Quotes are only considered
+// at the start of an element, or directly after one of quoteAfter, and
+// they are kept.
+// An empty s results in no elements.
+func splitQuoted(s string, sep string, quoteAfter ...string) ([]string, error) {
+	if s == "" {
+		return []string{}, nil
+	}
+
+	var elements []string
+	var quote byte
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case (s[i] == '"' || s[i] == '\'' || s[i] == '`') && quoteAllowed(s[start:i], quoteAfter):
+			quote = s[i]
+		case strings.HasPrefix(s[i:], sep):
+			elements = append(elements, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("missing closing quote")
+	}
+
+	return append(elements, s[start:]), nil
+}
+
+// quoteAllowed returns whether a quote can start after the text before,
+// which is the case when before is empty or ends with one of quoteAfter,
+// ignoring surrounding spaces.
+func quoteAllowed(before string, quoteAfter []string) bool {
+	before = strings.TrimSpace(before)
+	if before == "" {
+		return true
+	}
+
+	for _, q := range quoteAfter {
+		if strings.HasSuffix(before, q) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleUnsigned parses s as unsigned integer making sure it fits the bit
+// size of the field's type. Negative numbers are not allowed, but like signed
+// integers, a leading plus sign is.
+func handleUnsigned(name string, field reflect.StructField, value reflect.Value, s *string) error {
+	if value.Kind() == reflect.Pointer {
+		if s == nil {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+	}
+
+	rt := elemType(value.Type())
+
+	var n uint64
+
+	if s != nil && *s != "" {
+		if (*s)[0] == '-' {
+			return &ErrSyntax{
+				EnvVar: name,
+				Reason: "negative number not allowed for " + rt.Kind().String(),
+			}
+		}
+
+		// like strconv.ParseInt, accept a single leading plus sign
+		var err error
+		n, err = strconv.ParseUint(strings.TrimPrefix(*s, "+"), 10, rt.Bits())
+		if err != nil {
+			return numericSyntaxError(name, rt, err)
+		}
+	}
+
+	if value.Kind() == reflect.Pointer {
+		p := reflect.New(rt)
+		p.Elem().SetUint(n)
+		value.Set(p)
+	} else {
+		value.SetUint(n)
+	}
+	return nil
+}
+
+// handleFloat parses s as floating point number making sure it fits the bit
+// size of the field's type. Only plain decimal notation is accepted unless
+// the envVar-tag has the extendedFloat option.
+func handleFloat(name string, field reflect.StructField, value reflect.Value, s *string) error {
+	if value.Kind() == reflect.Pointer {
+		if s == nil {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+	}
+
+	rt := elemType(value.Type())
+
+	var n float64
+
+	if s != nil && *s != "" {
+		if err := checkFloatNotation(name, field, *s, false); err != nil {
+			return err
+		}
+
+		var err error
+		n, err = strconv.ParseFloat(*s, rt.Bits())
+		if err != nil {
+			return numericSyntaxError(name, rt, err)
+		}
+	}
+
+	if value.Kind() == reflect.Pointer {
+		p := reflect.New(rt)
+		p.Elem().SetFloat(n)
+		value.Set(p)
+	} else {
+		value.SetFloat(n)
+	}
+	return nil
+}
+
+// handleComplex parses s as complex number making sure it fits the bit
+// size of the field's type. Like handleFloat, only plain decimal notation
+// is accepted unless the envVar-tag has the extendedFloat option.
+func handleComplex(name string, field reflect.StructField, value reflect.Value, s *string) error {
+	if value.Kind() == reflect.Pointer {
+		if s == nil {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+	}
+
+	rt := elemType(value.Type())
+
+	var n complex128
+
+	if s != nil && *s != "" {
+		if err := checkFloatNotation(name, field, *s, true); err != nil {
+			return err
+		}
+
+		var err error
+		n, err = strconv.ParseComplex(*s, rt.Bits())
+		if err != nil {
+			return numericSyntaxError(name, rt, err)
+		}
+	}
+
+	if value.Kind() == reflect.Pointer {
+		p := reflect.New(rt)
+		p.Elem().SetComplex(n)
+		value.Set(p)
+	} else {
+		value.SetComplex(n)
+	}
+	return nil
+}
+
+// checkFloatNotation returns an error when s is not written using plain
+// decimal notation, for example `1e-3`, `0x1p-2`, `Inf`, or `NaN`, and
+// the field does not have the extendedFloat option set.
+// When isComplex is true, parentheses and the imaginary unit are allowed.
+func checkFloatNotation(name string, field reflect.StructField, s string, isComplex bool) error {
+	if hasTagOption(field, optExtendedFloat) {
+		return nil
+	}
+
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9', c == '.', c == '-', c == '+':
+		case isComplex && (c == 'i' || c == '(' || c == ')'):
+		default:
+			return &ErrSyntax{
+				EnvVar: name,
+				Reason: "notation requires option " + optExtendedFloat,
+			}
+		}
+	}
+
+	return nil
+}
+
+// numericSyntaxError returns the ErrSyntax for err which was returned by
+// one of the strconv parse functions for a field of type rt.
+func numericSyntaxError(name string, rt reflect.Type, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return &ErrSyntax{
+			EnvVar: name,
+			Reason: "out of range for " + rt.Kind().String(),
+		}
+	}
+
+	return &ErrSyntax{
+		EnvVar: name,
+		Reason: "number not parsable",
+	}
+}
+
+// elemType returns the element type when rt is a pointer, otherwise rt.
+func elemType(rt reflect.Type) reflect.Type {
+	if rt.Kind() == reflect.Pointer {
+		return rt.Elem()
+	}
+	return rt
+}
+
 func handleBoolean(name string, field reflect.StructField, value reflect.Value, s *string) error {
-	if field.Type.Kind() == reflect.Pointer {
+	if value.Kind() == reflect.Pointer {
 		if s == nil {
 			value.Set(reflect.Zero(value.Type()))
 			return nil
@@ -223,15 +1043,18 @@ func handleBoolean(name string, field reflect.StructField, value reflect.Value,
 
 func envVarFromStruct(name string, s any) (any, error) {
 	rv := reflect.Indirect(reflect.ValueOf(s))
-	rt := rv.Type()
-	if rt.Kind() != reflect.Struct {
-		panic(fmt.Sprintf("dest must be non-nil struct (was %s)", rt.Kind()))
+	if rv.Kind() != reflect.Struct {
+		return nil, &ErrInvalidDestination{
+			Type:   reflect.TypeOf(s),
+			Reason: "must be a struct or a non-nil pointer to a struct",
+		}
 	}
+	rt := rv.Type()
 
 	for i := 0; i < rt.NumField(); i++ {
 		rtf := rt.Field(i)
 
-		envVar := rtf.Tag.Get("envVar")
+		envVar, _ := parseTagEnvVar(rtf)
 
 		if envVar == "" {
 			continue
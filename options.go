@@ -0,0 +1,49 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+// Option configures how environment variables are read and stored in the
+// destination struct.
+type Option func(*decodeConfig)
+
+type decodeConfig struct {
+	allErrors    bool
+	rejectExport bool
+	provenance   *Provenance
+	origin       func(name string) origin // where variables were found; set internally
+}
+
+// AllErrors sets whether all fields are processed even when errors occur.
+// When all is true, every error is collected and returned as a single
+// ErrDecoding; otherwise, the first error is returned.
+func AllErrors(all bool) Option {
+	return func(c *decodeConfig) {
+		c.allErrors = all
+	}
+}
+
+// RecordProvenance records in p where the value of each field was found,
+// for example, in which dot-env file and on which line. See Provenance.
+func RecordProvenance(p *Provenance) Option {
+	return func(c *decodeConfig) {
+		c.provenance = p
+	}
+}
+
+// AllowExport sets whether variable names in dot-env files can be prefixed
+// with the `export` keyword, as in shell scripts, for example,
+// `export HOME=/home/alice`. It is allowed by default; when allow is false,
+// the keyword results in ErrSyntax. This has no effect on systemd
+// environment files, which do not support the keyword.
+func AllowExport(allow bool) Option {
+	return func(c *decodeConfig) {
+		c.rejectExport = !allow
+	}
+}
+
+// withOrigin sets how the origin of variables is looked up.
+func withOrigin(fn func(name string) origin) Option {
+	return func(c *decodeConfig) {
+		c.origin = fn
+	}
+}
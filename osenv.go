@@ -2,19 +2,36 @@ package envs
 
 import (
 	"os"
+	"slices"
 
 	"github.com/golistic/xgo/xstrings"
 )
 
 type envVarMap map[string]*string
 
+// has returns whether variable name is available in m.
+func (m envVarMap) has(name string) bool {
+	_, ok := m[name]
+	return ok
+}
+
 // OSEnviron gets variables from the operating system's environment and
 // stores the values in the struct dest.
 //
 // This function uses Go's os.Environ.
 //
-// Panics when dest is non-pointer, nil, or not a struct.
-func OSEnviron(dest any) error {
+// Options, like AllErrors, configure how the variables are stored in dest.
+//
+// Returns ErrInvalidDestination when dest is not a non-nil pointer to
+// a struct.
+func OSEnviron(dest any, options ...Option) error {
+	return reflectMapToStruct(osEnvVarMap(), dest, append(slices.Clip(options), withOrigin(func(string) origin {
+		return origin{source: sourceOS}
+	}))...)
+}
+
+// osEnvVarMap returns the variables of the operating system's environment.
+func osEnvVarMap() envVarMap {
 	src := envVarMap{}
 
 	for _, s := range os.Environ() {
@@ -26,5 +43,24 @@ func OSEnviron(dest any) error {
 		}
 	}
 
-	return reflectMapToStruct(src, dest)
+	return src
+}
+
+// Decode stores the variables found in vars in the struct dest. Unlike
+// the other functions, Decode processes all fields and returns all errors
+// at once as ErrDecoding. Use the AllErrors option to return only the
+// first error instead.
+//
+// Returns ErrInvalidDestination when dest is not a non-nil pointer to
+// a struct.
+func Decode(dest any, vars map[string]string, options ...Option) error {
+	src := envVarMap{}
+	for k, v := range vars {
+		src[k] = xstrings.Pointer(v)
+	}
+
+	options = append([]Option{AllErrors(true)}, options...)
+	return reflectMapToStruct(src, dest, append(slices.Clip(options), withOrigin(func(string) origin {
+		return origin{source: sourceMap}
+	}))...)
 }
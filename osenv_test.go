@@ -3,12 +3,15 @@
 package envs
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golistic/xgo/xt"
 )
@@ -120,6 +123,665 @@ func TestOSEnviron(t *testing.T) {
 		}
 	})
 
+	t.Run("syntax: signed number out of range", func(t *testing.T) {
+		var cases = map[string]struct {
+			value  string
+			expErr string
+		}{
+			"too big": {
+				value:  "300",
+				expErr: "NUMBER8_ie93k: syntax error (out of range for int8)",
+			},
+			"too small": {
+				value:  "-129",
+				expErr: "NUMBER8_ie93k: syntax error (out of range for int8)",
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				xt.OK(t, os.Setenv("NUMBER8_ie93k", c.value))
+				err := OSEnviron(&struct {
+					Number8 int8 `envVar:"NUMBER8_ie93k"`
+				}{})
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+
+				err = OSEnviron(&struct {
+					Number8 *int8 `envVar:"NUMBER8_ie93k"`
+				}{})
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+			})
+		}
+		xt.OK(t, os.Unsetenv("NUMBER8_ie93k"))
+	})
+
+	t.Run("int pointer variables respect bit size", func(t *testing.T) {
+		env := struct {
+			Number8  *int8  `envVar:"PTR_NUMBER8_ie93k"`
+			Number16 *int16 `envVar:"PTR_NUMBER16_ie93k"`
+			Number32 *int32 `envVar:"PTR_NUMBER32_ie93k"`
+		}{}
+		xt.OK(t, os.Setenv("PTR_NUMBER8_ie93k", "-128"))
+		xt.OK(t, os.Setenv("PTR_NUMBER16_ie93k", "32767"))
+		xt.OK(t, OSEnviron(&env))
+		xt.Eq(t, int8(-128), *env.Number8)
+		xt.Eq(t, int16(32767), *env.Number16)
+		xt.Eq(t, nil, env.Number32)
+		xt.OK(t, os.Unsetenv("PTR_NUMBER8_ie93k"))
+		xt.OK(t, os.Unsetenv("PTR_NUMBER16_ie93k"))
+	})
+
+	t.Run("uint variable set in environment", func(t *testing.T) {
+		var cases = []struct {
+			field string // field name from envUnsigned struct
+			exp   any
+		}{
+			{field: "Unsigned", exp: uint(546)},
+			{field: "Unsigned8", exp: uint8(255)},
+			{field: "Unsigned16", exp: uint16(65535)},
+			{field: "Unsigned32", exp: uint32(323232)},
+			{field: "Unsigned64", exp: uint64(18446744073709551615)},
+			{field: "UnsignedPtr", exp: uintptr(0xc000)},
+		}
+
+		env := envUnsigned{}
+
+		for _, c := range cases {
+			envKey := strings.ToUpper(c.field)
+			t.Run(fmt.Sprintf("%s value %v", envKey, c.exp), func(t *testing.T) {
+				xt.OK(t, os.Setenv(envKey, fmt.Sprintf("%d", c.exp)))
+				xt.OK(t, OSEnviron(&env))
+				rv := reflect.ValueOf(env)
+				rf := rv.FieldByName(c.field)
+				xt.Eq(t, fmt.Sprintf("%d", c.exp), fmt.Sprintf("%d", rf.Uint()))
+				xt.OK(t, os.Unsetenv(envKey))
+			})
+		}
+
+		t.Run("pointer values", func(t *testing.T) {
+			env := struct {
+				Port  *uint16 `envVar:"PTR_PORT_dk3il"`
+				Naked *uint32 `envVar:"PTR_NAKED_dk3il"`
+			}{}
+			xt.OK(t, os.Setenv("PTR_PORT_dk3il", "8080"))
+			xt.OK(t, OSEnviron(&env))
+			xt.Eq(t, uint16(8080), *env.Port)
+			xt.Eq(t, nil, env.Naked)
+			xt.OK(t, os.Unsetenv("PTR_PORT_dk3il"))
+		})
+	})
+
+	t.Run("syntax: unsigned number", func(t *testing.T) {
+		var cases = map[string]struct {
+			value  string
+			expErr string
+		}{
+			"out of range": {
+				value:  "70000",
+				expErr: "PORT_d9wk3: syntax error (out of range for uint16)",
+			},
+			"negative": {
+				value:  "-1",
+				expErr: "PORT_d9wk3: syntax error (negative number not allowed for uint16)",
+			},
+			"not parsable": {
+				value:  "eighty",
+				expErr: "PORT_d9wk3: syntax error (number not parsable)",
+			},
+			"plus sign": {
+				value: "+8080",
+			},
+			"two plus signs": {
+				value:  "++8080",
+				expErr: "PORT_d9wk3: syntax error (number not parsable)",
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				for _, ptr := range []bool{false, true} {
+					var err error
+					var port uint16
+					xt.OK(t, os.Setenv("PORT_d9wk3", c.value))
+					if ptr {
+						env := &struct {
+							Port *uint16 `envVar:"PORT_d9wk3"`
+						}{}
+						if err = OSEnviron(env); err == nil {
+							port = *env.Port
+						}
+					} else {
+						env := &struct {
+							Port uint16 `envVar:"PORT_d9wk3"`
+						}{}
+						err = OSEnviron(env)
+						port = env.Port
+					}
+					if c.expErr == "" {
+						xt.OK(t, err)
+						xt.Eq(t, uint16(8080), port)
+						continue
+					}
+					xt.KO(t, err)
+					xt.Eq(t, c.expErr, err.Error())
+				}
+			})
+		}
+		xt.OK(t, os.Unsetenv("PORT_d9wk3"))
+	})
+
+	t.Run("floating point and complex numbers", func(t *testing.T) {
+		env := struct {
+			Float32    float32     `envVar:"FLOAT32_dk39s"`
+			Float64    float64     `envVar:"FLOAT64_dk39s" default:"0.25"`
+			PtrFloat   *float64    `envVar:"PTR_FLOAT_dk39s"`
+			NakedFloat *float32    `envVar:"PTR_FLOAT_naked_dk39s"`
+			Complex64  complex64   `envVar:"COMPLEX64_dk39s"`
+			PtrComplex *complex128 `envVar:"PTR_COMPLEX_dk39s"`
+		}{}
+
+		xt.OK(t, os.Setenv("FLOAT32_dk39s", "1.5"))
+		xt.OK(t, os.Setenv("PTR_FLOAT_dk39s", "-0.001"))
+		xt.OK(t, os.Setenv("COMPLEX64_dk39s", "1.5+2i"))
+		xt.OK(t, os.Setenv("PTR_COMPLEX_dk39s", "(3-4.5i)"))
+		xt.OK(t, OSEnviron(&env))
+		xt.Eq(t, float32(1.5), env.Float32)
+		xt.Eq(t, 0.25, env.Float64)
+		xt.Eq(t, -0.001, *env.PtrFloat)
+		xt.Eq(t, nil, env.NakedFloat)
+		xt.Eq(t, complex64(complex(1.5, 2)), env.Complex64)
+		xt.Eq(t, complex(3, -4.5), *env.PtrComplex)
+
+		for _, k := range []string{"FLOAT32_dk39s", "PTR_FLOAT_dk39s", "COMPLEX64_dk39s", "PTR_COMPLEX_dk39s"} {
+			xt.OK(t, os.Unsetenv(k))
+		}
+	})
+
+	t.Run("extended floating point notation", func(t *testing.T) {
+		var cases = map[string]func(t *testing.T, f float64){
+			"1e-3":   func(t *testing.T, f float64) { xt.Eq(t, 0.001, f) },
+			"0x1p-2": func(t *testing.T, f float64) { xt.Eq(t, 0.25, f) },
+			"Inf":    func(t *testing.T, f float64) { xt.Assert(t, math.IsInf(f, 1)) },
+			"-inf":   func(t *testing.T, f float64) { xt.Assert(t, math.IsInf(f, -1)) },
+			"NaN":    func(t *testing.T, f float64) { xt.Assert(t, math.IsNaN(f)) },
+		}
+
+		for value, check := range cases {
+			t.Run(value, func(t *testing.T) {
+				xt.OK(t, os.Setenv("FLOAT_d8wk2", value))
+
+				env := struct {
+					Float float64 `envVar:"FLOAT_d8wk2,extendedFloat"`
+				}{}
+				xt.OK(t, OSEnviron(&env))
+				check(t, env.Float)
+
+				err := OSEnviron(&struct {
+					Float float64 `envVar:"FLOAT_d8wk2"`
+				}{})
+				xt.KO(t, err)
+				xt.Eq(t, "FLOAT_d8wk2: syntax error (notation requires option extendedFloat)", err.Error())
+			})
+		}
+
+		t.Run("complex", func(t *testing.T) {
+			xt.OK(t, os.Setenv("FLOAT_d8wk2", "1e2+NaNi"))
+			env := struct {
+				Complex complex128 `envVar:"FLOAT_d8wk2,extendedFloat"`
+			}{}
+			xt.OK(t, OSEnviron(&env))
+			xt.Eq(t, 100.0, real(env.Complex))
+			xt.Assert(t, math.IsNaN(imag(env.Complex)))
+
+			err := OSEnviron(&struct {
+				Complex complex128 `envVar:"FLOAT_d8wk2"`
+			}{})
+			xt.KO(t, err)
+			xt.Eq(t, "FLOAT_d8wk2: syntax error (notation requires option extendedFloat)", err.Error())
+		})
+
+		xt.OK(t, os.Unsetenv("FLOAT_d8wk2"))
+	})
+
+	t.Run("syntax: floating point number", func(t *testing.T) {
+		var cases = map[string]struct {
+			value  string
+			expErr string
+		}{
+			"out of range": {
+				value:  "1e39",
+				expErr: "FLOAT_x8dk3: syntax error (out of range for float32)",
+			},
+			"not parsable": {
+				value:  "1.2.3",
+				expErr: "FLOAT_x8dk3: syntax error (number not parsable)",
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				xt.OK(t, os.Setenv("FLOAT_x8dk3", c.value))
+				err := OSEnviron(&struct {
+					Float *float32 `envVar:"FLOAT_x8dk3,extendedFloat"`
+				}{})
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+			})
+		}
+		xt.OK(t, os.Unsetenv("FLOAT_x8dk3"))
+	})
+
+	t.Run("slices", func(t *testing.T) {
+		env := struct {
+			Hosts     []string        `envVar:"HOSTS_s8dk2"`
+			Ports     []uint16        `envVar:"PORTS_s8dk2" sep:";"`
+			Durations []time.Duration `envVar:"DURATIONS_s8dk2"`
+			Flags     []bool          `envVar:"FLAGS_s8dk2" default:"on,off"`
+			PtrInts   *[]int          `envVar:"PTR_INTS_s8dk2"`
+			Names     []*string       `envVar:"NAMES_s8dk2"`
+			Naked     []string        `envVar:"NAKED_s8dk2"`
+			Empty     []string        `envVar:"EMPTY_s8dk2"`
+		}{}
+
+		xt.OK(t, os.Setenv("HOSTS_s8dk2", ` a.example , "b,c.example",'d.example' `))
+		xt.OK(t, os.Setenv("PORTS_s8dk2", "80;443"))
+		xt.OK(t, os.Setenv("DURATIONS_s8dk2", "1s,2m"))
+		xt.OK(t, os.Setenv("PTR_INTS_s8dk2", "-1,2"))
+		xt.OK(t, os.Setenv("NAMES_s8dk2", "it's,fine"))
+		xt.OK(t, os.Setenv("EMPTY_s8dk2", ""))
+		xt.OK(t, OSEnviron(&env))
+
+		xt.Eq(t, []string{"a.example", "b,c.example", "d.example"}, env.Hosts)
+		xt.Eq(t, []uint16{80, 443}, env.Ports)
+		xt.Eq(t, []time.Duration{time.Second, 2 * time.Minute}, env.Durations)
+		xt.Eq(t, []bool{true, false}, env.Flags)
+		xt.Eq(t, []int{-1, 2}, *env.PtrInts)
+		xt.Eq(t, 2, len(env.Names))
+		xt.Eq(t, "it's", *env.Names[0])
+		xt.Eq(t, "fine", *env.Names[1])
+		xt.Eq(t, nil, env.Naked)
+		xt.Assert(t, env.Empty != nil)
+		xt.Eq(t, 0, len(env.Empty))
+
+		for _, k := range []string{"HOSTS_s8dk2", "PORTS_s8dk2", "DURATIONS_s8dk2",
+			"PTR_INTS_s8dk2", "NAMES_s8dk2", "EMPTY_s8dk2"} {
+			xt.OK(t, os.Unsetenv(k))
+		}
+	})
+
+	t.Run("syntax: slices", func(t *testing.T) {
+		var cases = map[string]struct {
+			value  string
+			expErr string
+		}{
+			"element not parsable": {
+				value:  "1,2,three",
+				expErr: "INTS_k39dk: syntax error (element 2: number not parsable)",
+			},
+			"element out of range": {
+				value:  "1,300",
+				expErr: "INTS_k39dk: syntax error (element 1: out of range for int8)",
+			},
+			"missing closing quote": {
+				value:  `1,"2`,
+				expErr: "INTS_k39dk: syntax error (missing closing quote)",
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				xt.OK(t, os.Setenv("INTS_k39dk", c.value))
+				err := OSEnviron(&struct {
+					Ints []int8 `envVar:"INTS_k39dk"`
+				}{})
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+			})
+		}
+		xt.OK(t, os.Unsetenv("INTS_k39dk"))
+	})
+
+	t.Run("maps", func(t *testing.T) {
+		env := struct {
+			Labels   map[string]string         `envVar:"LABELS_m3kd9"`
+			Limits   map[string]int            `envVar:"LIMITS_m3kd9" sep:";" kvSep:"="`
+			Timeouts *map[string]time.Duration `envVar:"TIMEOUTS_m3kd9"`
+			Ports    map[uint16]bool           `envVar:"PORTS_m3kd9" default:"80:on,443:off"`
+			Naked    map[string]string         `envVar:"NAKED_m3kd9"`
+			Empty    map[string]string         `envVar:"EMPTY_m3kd9"`
+		}{}
+
+		xt.OK(t, os.Setenv("LABELS_m3kd9", `team:core, tier : gold,url:http://a.example,"a:b":'c,d'`))
+		xt.OK(t, os.Setenv("LIMITS_m3kd9", "cpu=2;memory=512"))
+		xt.OK(t, os.Setenv("TIMEOUTS_m3kd9", "read:1s,write:2s"))
+		xt.OK(t, os.Setenv("EMPTY_m3kd9", ""))
+		xt.OK(t, OSEnviron(&env))
+
+		xt.Eq(t, map[string]string{
+			"team": "core",
+			"tier": "gold",
+			"url":  "http://a.example",
+			"a:b":  "c,d",
+		}, env.Labels)
+		xt.Eq(t, map[string]int{"cpu": 2, "memory": 512}, env.Limits)
+		xt.Eq(t, map[string]time.Duration{"read": time.Second, "write": 2 * time.Second}, *env.Timeouts)
+		xt.Eq(t, map[uint16]bool{80: true, 443: false}, env.Ports)
+		xt.Eq(t, nil, env.Naked)
+		xt.Assert(t, env.Empty != nil)
+		xt.Eq(t, 0, len(env.Empty))
+
+		for _, k := range []string{"LABELS_m3kd9", "LIMITS_m3kd9", "TIMEOUTS_m3kd9", "EMPTY_m3kd9"} {
+			xt.OK(t, os.Unsetenv(k))
+		}
+	})
+
+	t.Run("syntax: maps", func(t *testing.T) {
+		type limits struct {
+			Limits map[string]int `envVar:"LIMITS_d93kx"`
+		}
+		type limitsKeys struct {
+			Limits map[uint8]int `envVar:"LIMITS_d93kx"`
+		}
+
+		var cases = map[string]struct {
+			value  string
+			dest   any
+			expErr string
+		}{
+			"value not parsable": {
+				value:  "cpu:2,memory:lots",
+				dest:   &limits{},
+				expErr: `LIMITS_d93kx: syntax error (pair "memory:lots": number not parsable)`,
+			},
+			"key not parsable": {
+				value:  "1:2,300:1",
+				dest:   &limitsKeys{},
+				expErr: `LIMITS_d93kx: syntax error (pair "300:1": out of range for uint8)`,
+			},
+			"missing key/value separator": {
+				value:  "cpu:2,memory",
+				dest:   &limits{},
+				expErr: `LIMITS_d93kx: syntax error (pair "memory": missing key/value separator)`,
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				xt.OK(t, os.Setenv("LIMITS_d93kx", c.value))
+				err := OSEnviron(c.dest)
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+			})
+		}
+		xt.OK(t, os.Unsetenv("LIMITS_d93kx"))
+	})
+
+	t.Run("nested structs", func(t *testing.T) {
+		type server struct {
+			Host string `envVar:"HOST" default:"localhost"`
+			Port uint16 `envVar:"PORT"`
+		}
+
+		type database struct {
+			Name    string  `envVar:"NAME"`
+			Replica *server `envPrefix:"REPLICA_"`
+		}
+
+		env := struct {
+			Database database `envPrefix:"DB_n3kd8_"`
+			Cache    *server  `envPrefix:"CACHE_n3kd8_"`
+			HTTP     *server  `envPrefix:"HTTP_n3kd8_"`
+			Plain    server
+		}{}
+
+		xt.OK(t, os.Setenv("DB_n3kd8_NAME", "app"))
+		xt.OK(t, os.Setenv("DB_n3kd8_REPLICA_PORT", "5433"))
+		xt.OK(t, os.Setenv("CACHE_n3kd8_HOST", "cache.example"))
+		xt.OK(t, OSEnviron(&env))
+
+		xt.Eq(t, "app", env.Database.Name)
+		xt.Eq(t, "localhost", env.Database.Replica.Host)
+		xt.Eq(t, uint16(5433), env.Database.Replica.Port)
+		xt.Eq(t, "cache.example", env.Cache.Host)
+		xt.Eq(t, uint16(0), env.Cache.Port)
+		xt.Eq(t, nil, env.HTTP)
+		xt.Eq(t, "localhost", env.Plain.Host)
+
+		for _, k := range []string{"DB_n3kd8_NAME", "DB_n3kd8_REPLICA_PORT", "CACHE_n3kd8_HOST"} {
+			xt.OK(t, os.Unsetenv(k))
+		}
+	})
+
+	t.Run("syntax: nested structs", func(t *testing.T) {
+		env := struct {
+			HTTP struct {
+				Port uint16 `envVar:"PORT"`
+			} `envPrefix:"HTTP_x9dk3_"`
+		}{}
+
+		xt.OK(t, os.Setenv("HTTP_x9dk3_PORT", "70000"))
+		err := OSEnviron(&env)
+		xt.KO(t, err)
+		xt.Eq(t, "HTTP_x9dk3_PORT: syntax error (out of range for uint16)", err.Error())
+		xt.OK(t, os.Unsetenv("HTTP_x9dk3_PORT"))
+	})
+
+	t.Run("nested struct referencing itself", func(t *testing.T) {
+		env := struct {
+			Node *envNode `envPrefix:"NODE_d8ek2_"`
+		}{}
+		xt.OK(t, OSEnviron(&env))
+		xt.Eq(t, nil, env.Node)
+	})
+
+	t.Run("options of caller are not modified", func(t *testing.T) {
+		options := make([]Option, 1, 2)
+		options[0] = AllErrors(true)
+
+		var env struct {
+			Name string `envVar:"NAME"`
+		}
+		xt.OK(t, Decode(&env, map[string]string{"NAME": "x"}, options...))
+		xt.Assert(t, options[:2][1] == nil)
+	})
+
+	t.Run("nested struct referencing itself without prefix", func(t *testing.T) {
+		var list envList
+		xt.OK(t, Decode(&list, map[string]string{"VALUE": "x"}))
+		xt.Eq(t, "x", list.Value)
+		xt.Assert(t, list.Next == nil)
+
+		var env struct {
+			List *envList `envPrefix:"LIST_"`
+		}
+		xt.OK(t, Decode(&env, map[string]string{"LIST_VALUE": "y"}))
+		xt.Eq(t, "y", env.List.Value)
+		xt.Assert(t, env.List.Next == nil)
+	})
+
+	t.Run("embedded structs", func(t *testing.T) {
+		xt.OK(t, os.Setenv("LOG_LEVEL_e8dk3", "debug"))
+		xt.OK(t, os.Setenv("SERVICE_NAME_e8dk3", "billing"))
+		xt.OK(t, os.Setenv("DB_e8dk3_HOST", "db.example"))
+		defer func() {
+			for _, k := range []string{"LOG_LEVEL_e8dk3", "SERVICE_NAME_e8dk3", "DB_e8dk3_HOST"} {
+				xt.OK(t, os.Unsetenv(k))
+			}
+		}()
+
+		t.Run("value", func(t *testing.T) {
+			env := struct {
+				envCommon
+				Port int `envVar:"PORT_e8dk3" default:"8080"`
+			}{}
+			xt.OK(t, OSEnviron(&env))
+			xt.Eq(t, "debug", env.LogLevel)
+			xt.Eq(t, "billing", env.ServiceName)
+			xt.Eq(t, 8080, env.Port)
+		})
+
+		t.Run("pointer", func(t *testing.T) {
+			env := struct {
+				*EnvCommon
+			}{}
+			xt.OK(t, OSEnviron(&env))
+			xt.Eq(t, "debug", env.LogLevel)
+			xt.Eq(t, "billing", env.ServiceName)
+		})
+
+		t.Run("pointer not allocated when no variables available", func(t *testing.T) {
+			env := struct {
+				*EnvCommon `envPrefix:"NOT_SET_e8dk3_"`
+			}{}
+			xt.OK(t, OSEnviron(&env))
+			xt.Eq(t, nil, env.EnvCommon)
+		})
+
+		t.Run("with prefix within nested struct", func(t *testing.T) {
+			env := struct {
+				Database struct {
+					envServer `envPrefix:"DB_e8dk3_"`
+				}
+			}{}
+			xt.OK(t, OSEnviron(&env))
+			xt.Eq(t, "db.example", env.Database.Host)
+		})
+
+		t.Run("outer field shadows embedded field", func(t *testing.T) {
+			env := struct {
+				envCommon
+				Level string `envVar:"LOG_LEVEL_e8dk3"`
+			}{}
+			env.LogLevel = "untouched"
+			xt.OK(t, OSEnviron(&env))
+			xt.Eq(t, "debug", env.Level)
+			xt.Eq(t, "untouched", env.LogLevel)
+			xt.Eq(t, "billing", env.ServiceName)
+		})
+
+		t.Run("shallowest embedded field wins", func(t *testing.T) {
+			type level struct {
+				Level string `envVar:"LOG_LEVEL_e8dk3"`
+			}
+			type deeper struct {
+				envCommon
+			}
+			env := struct {
+				deeper
+				level
+			}{}
+			xt.OK(t, OSEnviron(&env))
+			xt.Eq(t, "debug", env.level.Level)
+			xt.Eq(t, "", env.deeper.LogLevel)
+		})
+
+		t.Run("error: unexported embedded pointer", func(t *testing.T) {
+			env := struct {
+				*envCommon
+			}{}
+			err := OSEnviron(&env)
+			xt.KO(t, err)
+			xt.Eq(t, "invalid destination *envs.envCommon "+
+				"(cannot set embedded pointer to unexported struct type)", err.Error())
+		})
+	})
+
+	t.Run("required and notEmpty variables", func(t *testing.T) {
+		type database struct {
+			Host string `envVar:"HOST,required"`
+		}
+
+		var cases = map[string]struct {
+			vars   map[string]string
+			dest   any
+			expErr string
+		}{
+			"required missing": {
+				dest: &struct {
+					URL string `envVar:"URL_r8dk3,required" default:"ignored"`
+				}{},
+				expErr: "URL_r8dk3: required variable missing (field URL)",
+			},
+			"required missing in nested struct": {
+				dest: &struct {
+					Database database `envPrefix:"DB_r8dk3_"`
+				}{},
+				expErr: "DB_r8dk3_HOST: required variable missing (field Database.Host)",
+			},
+			"required available but empty": {
+				vars: map[string]string{"URL_r8dk3": ""},
+				dest: &struct {
+					URL string `envVar:"URL_r8dk3,required"`
+				}{},
+			},
+			"notEmpty not available": {
+				dest: &struct {
+					URL string `envVar:"URL_r8dk3,notEmpty"`
+				}{},
+			},
+			"notEmpty available but empty": {
+				vars: map[string]string{"URL_r8dk3": "  "},
+				dest: &struct {
+					URL string `envVar:"URL_r8dk3,notEmpty"`
+				}{},
+				expErr: "URL_r8dk3: variable is empty (field URL)",
+			},
+			"notEmpty available but empty quotes": {
+				vars: map[string]string{"URL_r8dk3": `""`},
+				dest: &struct {
+					URL string `envVar:"URL_r8dk3, required, notEmpty"`
+				}{},
+				expErr: "URL_r8dk3: variable is empty (field URL)",
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				for k, v := range c.vars {
+					xt.OK(t, os.Setenv(k, v))
+				}
+				err := OSEnviron(c.dest)
+				for k := range c.vars {
+					xt.OK(t, os.Unsetenv(k))
+				}
+
+				if c.expErr == "" {
+					xt.OK(t, err)
+					return
+				}
+
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+				var errMissing *ErrMissing
+				xt.Assert(t, errors.As(err, &errMissing))
+			})
+		}
+	})
+
+	t.Run("all errors option", func(t *testing.T) {
+		env := struct {
+			Number  int  `envVar:"NUMBER_a9dk3"`
+			Boolean bool `envVar:"BOOL_a9dk3"`
+		}{}
+		xt.OK(t, os.Setenv("NUMBER_a9dk3", "one"))
+		xt.OK(t, os.Setenv("BOOL_a9dk3", "maybe"))
+
+		err := OSEnviron(&env)
+		xt.KO(t, err)
+		xt.Eq(t, "NUMBER_a9dk3: syntax error (number not parsable)", err.Error())
+
+		err = OSEnviron(&env, AllErrors(true))
+		xt.KO(t, err)
+		var errDecoding *ErrDecoding
+		xt.Assert(t, errors.As(err, &errDecoding))
+		xt.Eq(t, 2, len(errDecoding.Errs))
+
+		xt.OK(t, os.Unsetenv("NUMBER_a9dk3"))
+		xt.OK(t, os.Unsetenv("BOOL_a9dk3"))
+	})
+
 	t.Run("boolean", func(t *testing.T) {
 		var casesFalse = []string{"false", "False", "FALSE", "0", "off", "f", ""}
 		var casesTrue = []string{"true", "True", "TRUE", "1", "on", "12345", "t"}
@@ -164,36 +826,130 @@ func TestOSEnviron(t *testing.T) {
 		xt.Eq(t, "BOOL: syntax error (not a valid boolean value)", err.Error())
 	})
 
-	t.Run("panic: unsupported type", func(t *testing.T) {
+	t.Run("error: unsupported type", func(t *testing.T) {
 		var env = struct {
 			Something io.Writer `envVar:"SOMETHING"`
 		}{}
 		xt.OK(t, os.Setenv("SOMETHING", "goes into struct field of unsupported type"))
-		xt.Panics(t, func() {
-			_ = OSEnviron(&env)
-		})
+		err := OSEnviron(&env)
+		xt.KO(t, err)
+		xt.Eq(t, "SOMETHING: unsupported type 'io.Writer' for field Something", err.Error())
+		var errUnsupported *ErrUnsupportedType
+		xt.Assert(t, errors.As(err, &errUnsupported))
 	})
 
-	t.Run("panic: destination not valid", func(t *testing.T) {
-		t.Run("must be pointer to struct", func(t *testing.T) {
-			foo := 1
-			xt.Panics(t, func() {
-				_ = OSEnviron(&foo)
-			})
-		})
+	t.Run("error: unexported field", func(t *testing.T) {
+		var env = struct {
+			something string `envVar:"SOMETHING"`
+		}{}
+		err := OSEnviron(&env)
+		xt.KO(t, err)
+		xt.Eq(t, "SOMETHING: field something is not exported", err.Error())
+		xt.Eq(t, "", env.something)
+	})
 
-		t.Run("must be initialized (not nil)", func(t *testing.T) {
-			xt.Panics(t, func() {
-				var env *testEnv
-				_ = OSEnviron(&env)
-			})
-		})
+	t.Run("error: destination not valid", func(t *testing.T) {
+		var cases = map[string]struct {
+			dest   any
+			expErr string
+		}{
+			"must be pointer to struct": {
+				dest:   func() any { foo := 1; return &foo }(),
+				expErr: "invalid destination *int (must point to a struct)",
+			},
+			"must be initialized (not nil)": {
+				dest:   func() any { var env *testEnv; return &env }(),
+				expErr: "invalid destination **envs.testEnv (must point to a struct)",
+			},
+			"must be pointer": {
+				dest:   testEnv{},
+				expErr: "invalid destination envs.testEnv (must be a non-nil pointer to a struct)",
+			},
+			"cannot be nil pointer": {
+				dest:   (*testEnv)(nil),
+				expErr: "invalid destination *envs.testEnv (must be a non-nil pointer to a struct)",
+			},
+			"cannot be nil": {
+				dest:   nil,
+				expErr: "invalid destination <nil> (must be a non-nil pointer to a struct)",
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				err := OSEnviron(c.dest)
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+				var errInvalid *ErrInvalidDestination
+				xt.Assert(t, errors.As(err, &errInvalid))
+
+				err = NodeJSDotEnv(c.dest, strings.NewReader("NUMBER=1"))
+				xt.Assert(t, errors.As(err, &errInvalid))
 
-		t.Run("cannot be nil", func(t *testing.T) {
-			xt.Panics(t, func() {
-				_ = OSEnviron(nil)
+				err = DjangoDotEnv(c.dest, strings.NewReader("NUMBER=1"))
+				xt.Assert(t, errors.As(err, &errInvalid))
 			})
-		})
+		}
+	})
+}
+
+func TestDecode(t *testing.T) {
+	type server struct {
+		Port uint16 `envVar:"PORT"`
+	}
+
+	type config struct {
+		URL     string        `envVar:"URL,required"`
+		Number  int           `envVar:"NUMBER"`
+		Timeout time.Duration `envVar:"TIMEOUT"`
+		HTTP    server        `envPrefix:"HTTP_"`
+		Name    string        `envVar:"NAME"`
+	}
+
+	vars := map[string]string{
+		"NUMBER":    "not a number",
+		"TIMEOUT":   "5 minutes",
+		"HTTP_PORT": "70000",
+		"NAME":      "alice",
+	}
+
+	t.Run("all errors by default", func(t *testing.T) {
+		dest := &config{}
+		err := Decode(dest, vars)
+		xt.KO(t, err)
+
+		var errDecoding *ErrDecoding
+		xt.Assert(t, errors.As(err, &errDecoding))
+		xt.Eq(t, 4, len(errDecoding.Errs))
+
+		exp := "URL: required variable missing (field URL)\n" +
+			"NUMBER: syntax error (number not parsable) (field Number)\n" +
+			"TIMEOUT: syntax error (not parsable as Go duration string) (field Timeout)\n" +
+			"HTTP_PORT: syntax error (out of range for uint16) (field HTTP.Port)"
+		xt.Eq(t, exp, err.Error())
+
+		var errMissing *ErrMissing
+		xt.Assert(t, errors.As(err, &errMissing))
+		xt.Eq(t, "URL", errMissing.EnvVar)
+
+		var errSyntax *ErrSyntax
+		xt.Assert(t, errors.As(err, &errSyntax))
+		xt.Eq(t, "Number", errSyntax.Field)
+
+		xt.Eq(t, "alice", dest.Name, "fields without errors are set")
+	})
+
+	t.Run("first error", func(t *testing.T) {
+		err := Decode(&config{}, vars, AllErrors(false))
+		xt.KO(t, err)
+		xt.Eq(t, "URL: required variable missing (field URL)", err.Error())
+	})
+
+	t.Run("no errors", func(t *testing.T) {
+		dest := &config{}
+		xt.OK(t, Decode(dest, map[string]string{"URL": "https://example.com", "HTTP_PORT": "80"}))
+		xt.Eq(t, "https://example.com", dest.URL)
+		xt.Eq(t, uint16(80), dest.HTTP.Port)
 	})
 }
 
@@ -205,6 +961,37 @@ type envNumbers struct {
 	Number64 int64 `envVar:"NUMBER64" default:"999"`
 }
 
+type envUnsigned struct {
+	Unsigned    uint    `envVar:"UNSIGNED" default:"999"`
+	Unsigned8   uint8   `envVar:"UNSIGNED8"`
+	Unsigned16  uint16  `envVar:"UNSIGNED16"`
+	Unsigned32  uint32  `envVar:"UNSIGNED32"`
+	Unsigned64  uint64  `envVar:"UNSIGNED64"`
+	UnsignedPtr uintptr `envVar:"UNSIGNEDPTR"`
+}
+
+type envCommon struct {
+	LogLevel    string `envVar:"LOG_LEVEL_e8dk3" default:"info"`
+	ServiceName string `envVar:"SERVICE_NAME_e8dk3"`
+}
+
+type EnvCommon envCommon
+
+type envServer struct {
+	Host string `envVar:"HOST" default:"localhost"`
+}
+
+type envNode struct {
+	Name string   `envVar:"NAME"`
+	Next *envNode `envPrefix:"NEXT_"`
+}
+
+// envList references itself without envPrefix-tag.
+type envList struct {
+	Value string `envVar:"VALUE"`
+	Next  *envList
+}
+
 type envQuoted struct {
 	Double   string `envVar:"DOUBLE_QUOTED"`
 	BackTick string `envVar:"BACKQUOTED"`
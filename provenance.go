@@ -0,0 +1,78 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names of the sources reported by Provenance. Sources of a Loader are
+// reported using their name.
+const (
+	sourceOS      = "os"
+	sourceMap     = "map"
+	sourceDotEnv  = "dotenv" // dot-env read from io.Reader
+	sourceDefault = "default"
+)
+
+// origin is where a variable was found.
+type origin struct {
+	source string
+	path   string
+	line   int
+}
+
+// Provenance reports where the value of each field was found. It is
+// filled using the RecordProvenance option, for example, to log the origin
+// of the configuration when the application starts.
+type Provenance struct {
+	Fields []FieldProvenance // in the order the fields were set
+}
+
+// FieldProvenance reports where the value of a field was found.
+type FieldProvenance struct {
+	Field  string // path of the struct field, for example, Database.Host
+	EnvVar string
+	// Source is "os" for the OS environment, the path of the dot-env file,
+	// "dotenv" for dot-env read from an io.Reader, "map" for Decode, the name
+	// of the Loader's source, or "default" for the default-tag. It is empty
+	// when the field was set to the zero value.
+	Source   string
+	FilePath string // file in which the variable is defined
+	Line     int    // line on which the variable is defined; 0 when unknown
+}
+
+func (fp FieldProvenance) String() string {
+	source := fp.Source
+	switch {
+	case source == "":
+		source = "not set"
+	case fp.Line > 0 && fp.FilePath != "":
+		source = fmt.Sprintf("%s:%d", fp.FilePath, fp.Line)
+	case fp.Line > 0:
+		source = fmt.Sprintf("%s line %d", source, fp.Line)
+	}
+
+	return fmt.Sprintf("%s (%s): %s", fp.Field, fp.EnvVar, source)
+}
+
+// Get returns the provenance of the field with path, for example,
+// "Database.Host", and whether it was set.
+func (p *Provenance) Get(field string) (FieldProvenance, bool) {
+	for _, fp := range p.Fields {
+		if fp.Field == field {
+			return fp, true
+		}
+	}
+	return FieldProvenance{}, false
+}
+
+// String returns the provenance of each field on a separate line.
+func (p *Provenance) String() string {
+	lines := make([]string, len(p.Fields))
+	for i, fp := range p.Fields {
+		lines[i] = fp.String()
+	}
+	return strings.Join(lines, "\n")
+}
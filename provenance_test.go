@@ -0,0 +1,92 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+)
+
+func TestRecordProvenance(t *testing.T) {
+	type database struct {
+		Host string `envVar:"HOST"`
+		Port int    `envVar:"PORT" default:"5432"`
+	}
+
+	type appEnv struct {
+		Mode     string   `envVar:"ENVS_PROV_MODE"`
+		User     string   `envVar:"ENVS_PROV_USER"`
+		Token    string   `envVar:"ENVS_PROV_TOKEN"`
+		Missing  string   `envVar:"ENVS_PROV_MISSING"`
+		Database database `envPrefix:"ENVS_PROV_DB_"`
+	}
+
+	t.Run("loader", func(t *testing.T) {
+		unsetEnv(t, "ENVS_PROV_USER", "ENVS_PROV_TOKEN", "ENVS_PROV_MISSING",
+			"ENVS_PROV_DB_HOST", "ENVS_PROV_DB_PORT")
+		t.Setenv("ENVS_PROV_MODE", "production")
+
+		dotEnv := writeDotEnv(t, ".env", "ENVS_PROV_MODE=development\n\nENVS_PROV_DB_HOST=db.example.com\n")
+
+		var prov Provenance
+		dest := &appEnv{}
+		xt.OK(t, NewLoader(
+			MapSource("secrets", map[string]string{"ENVS_PROV_TOKEN": "s3cr3t"}),
+			NodeJSDotEnvSource(dotEnv),
+			OSSource(),
+		).Load(dest, RecordProvenance(&prov)))
+
+		xt.Eq(t, []FieldProvenance{
+			{Field: "Mode", EnvVar: "ENVS_PROV_MODE", Source: "os"},
+			{Field: "User", EnvVar: "ENVS_PROV_USER"},
+			{Field: "Token", EnvVar: "ENVS_PROV_TOKEN", Source: "secrets"},
+			{Field: "Missing", EnvVar: "ENVS_PROV_MISSING"},
+			{Field: "Database.Host", EnvVar: "ENVS_PROV_DB_HOST", Source: dotEnv, FilePath: dotEnv, Line: 3},
+			{Field: "Database.Port", EnvVar: "ENVS_PROV_DB_PORT", Source: "default"},
+		}, prov.Fields)
+
+		fp, ok := prov.Get("Database.Host")
+		xt.Assert(t, ok)
+		xt.Eq(t, "Database.Host (ENVS_PROV_DB_HOST): "+dotEnv+":3", fp.String())
+
+		_, ok = prov.Get("Database.Name")
+		xt.Assert(t, !ok)
+	})
+
+	t.Run("dot-env file and reader", func(t *testing.T) {
+		type userEnv struct {
+			Username string `envVar:"USER"`
+			HomeDir  string `envVar:"HOME"`
+			Avatar   string `envVar:"AVATAR" default:"🐣"`
+		}
+
+		var prov Provenance
+		dest := &userEnv{}
+		xt.OK(t, NodeJSDotEnvFromFile(dest, "_test_data/js.example.env", RecordProvenance(&prov)))
+		fp, _ := prov.Get("Username")
+		xt.Eq(t, FieldProvenance{
+			Field: "Username", EnvVar: "USER",
+			Source: "_test_data/js.example.env", FilePath: "_test_data/js.example.env", Line: 2,
+		}, fp)
+
+		xt.OK(t, DjangoDotEnv(dest, strings.NewReader("\nHOME=/home/alice\n"), RecordProvenance(&prov)))
+		xt.Eq(t, "Username (USER): not set\n"+
+			"HomeDir (HOME): dotenv line 2\n"+
+			"Avatar (AVATAR): default", prov.String())
+	})
+
+	t.Run("OS environment and map", func(t *testing.T) {
+		t.Setenv("ENVS_PROV_MODE", "production")
+
+		var prov Provenance
+		xt.OK(t, OSEnviron(&appEnv{}, RecordProvenance(&prov)))
+		fp, _ := prov.Get("Mode")
+		xt.Eq(t, "os", fp.Source)
+
+		xt.OK(t, Decode(&appEnv{}, map[string]string{"ENVS_PROV_MODE": "test"}, RecordProvenance(&prov)))
+		fp, _ = prov.Get("Mode")
+		xt.Eq(t, "map", fp.Source)
+	})
+}
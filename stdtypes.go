@@ -0,0 +1,106 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// handleStdType sets value, or what it points to, using the result of parse.
+// When parse fails, a syntax error with reason is returned. An empty s
+// results in the zero value of T.
+func handleStdType[T any](name string, value reflect.Value, s *string, reason string,
+	parse func(string) (T, error)) error {
+
+	if value.Kind() == reflect.Pointer {
+		if s == nil {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+	}
+
+	var res T
+
+	if s != nil && *s != "" {
+		v, err := unquote(name, *s)
+		if err != nil {
+			return err
+		}
+
+		if res, err = parse(v); err != nil {
+			return &ErrSyntax{EnvVar: name, Reason: reason, Err: err}
+		}
+	}
+
+	if value.Kind() == reflect.Pointer {
+		value.Set(reflect.ValueOf(&res))
+	} else {
+		value.Set(reflect.ValueOf(res))
+	}
+	return nil
+}
+
+// handleTime parses s as time using the layout found in the layout-tag of
+// field, or time.RFC3339 when not available.
+func handleTime(name string, field reflect.StructField, value reflect.Value, s *string) error {
+	layout := field.Tag.Get(tagLayout)
+	if layout == "" {
+		layout = time.RFC3339
+	}
+
+	return handleStdType(name, value, s, fmt.Sprintf("not parsable as time using layout %q", layout),
+		func(v string) (time.Time, error) {
+			return time.Parse(layout, v)
+		})
+}
+
+// handleLocation loads the time zone location named s, for example,
+// "Europe/Brussels". Like time.LoadLocation, an empty s or "UTC" results
+// in UTC, and "Local" in the local time zone.
+func handleLocation(name string, value reflect.Value, s *string) error {
+	if s == nil {
+		value.Set(reflect.Zero(value.Type()))
+		return nil
+	}
+
+	v, err := unquote(name, *s)
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		return &ErrSyntax{EnvVar: name, Reason: "unknown time zone location", Err: err}
+	}
+
+	value.Set(reflect.ValueOf(loc))
+	return nil
+}
+
+func parseURL(s string) (url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return url.URL{}, err
+	}
+	return *u, nil
+}
+
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "IP address", Text: s}
+	}
+	return ip, nil
+}
+
+func parseIPNet(s string) (net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return net.IPNet{}, err
+	}
+	return *ipNet, nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package envs
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golistic/xgo/xt"
+)
+
+func TestStdTypes(t *testing.T) {
+	t.Run("values and pointers", func(t *testing.T) {
+		env := struct {
+			URL       url.URL        `envVar:"URL_s9dk3"`
+			PtrURL    *url.URL       `envVar:"PTR_URL_s9dk3"`
+			IP        net.IP         `envVar:"IP_s9dk3"`
+			IPNet     net.IPNet      `envVar:"IPNET_s9dk3"`
+			Addr      netip.Addr     `envVar:"ADDR_s9dk3"`
+			AddrPort  netip.AddrPort `envVar:"ADDRPORT_s9dk3"`
+			Prefix    *netip.Prefix  `envVar:"PREFIX_s9dk3"`
+			Prefixes  []netip.Prefix `envVar:"PREFIXES_s9dk3"`
+			Location  *time.Location `envVar:"TZ_s9dk3"`
+			Time      time.Time      `envVar:"TIME_s9dk3"`
+			Date      *time.Time     `envVar:"DATE_s9dk3" layout:"2006-01-02"`
+			NakedURL  *url.URL       `envVar:"NAKED_URL_s9dk3"`
+			NakedTime *time.Time     `envVar:"NAKED_TIME_s9dk3"`
+			NakedTZ   *time.Location `envVar:"NAKED_TZ_s9dk3"`
+			EmptyIP   net.IP         `envVar:"EMPTY_IP_s9dk3"`
+			EmptyAddr netip.Addr     `envVar:"EMPTY_ADDR_s9dk3"`
+			Default   netip.AddrPort `envVar:"DEFAULT_s9dk3" default:"127.0.0.1:8080"`
+		}{}
+
+		vars := map[string]string{
+			"URL_s9dk3":        "postgres://db.example:5432/app?sslmode=disable",
+			"PTR_URL_s9dk3":    `"https://example.com/path"`,
+			"IP_s9dk3":         "192.0.2.1",
+			"IPNET_s9dk3":      "10.1.2.3/8",
+			"ADDR_s9dk3":       "2001:db8::1",
+			"ADDRPORT_s9dk3":   "[::1]:443",
+			"PREFIX_s9dk3":     "192.0.2.0/24",
+			"PREFIXES_s9dk3":   "10.0.0.0/8, 172.16.0.0/12",
+			"TZ_s9dk3":         "UTC",
+			"TIME_s9dk3":       "2023-08-26T10:20:30Z",
+			"DATE_s9dk3":       "2023-08-26",
+			"EMPTY_IP_s9dk3":   "",
+			"EMPTY_ADDR_s9dk3": "",
+		}
+		for k, v := range vars {
+			xt.OK(t, os.Setenv(k, v))
+		}
+		defer func() {
+			for k := range vars {
+				xt.OK(t, os.Unsetenv(k))
+			}
+		}()
+
+		xt.OK(t, OSEnviron(&env))
+
+		xt.Eq(t, "postgres", env.URL.Scheme)
+		xt.Eq(t, "db.example:5432", env.URL.Host)
+		xt.Eq(t, "/app", env.URL.Path)
+		xt.Eq(t, "https://example.com/path", env.PtrURL.String())
+		xt.Eq(t, "192.0.2.1", env.IP.String())
+		xt.Eq(t, "10.0.0.0/8", env.IPNet.String())
+		xt.Eq(t, netip.MustParseAddr("2001:db8::1"), env.Addr)
+		xt.Eq(t, netip.MustParseAddrPort("[::1]:443"), env.AddrPort)
+		xt.Eq(t, netip.MustParsePrefix("192.0.2.0/24"), *env.Prefix)
+		xt.Eq(t, []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("172.16.0.0/12"),
+		}, env.Prefixes)
+		xt.Assert(t, env.Location == time.UTC)
+		xt.Eq(t, time.Date(2023, 8, 26, 10, 20, 30, 0, time.UTC), env.Time)
+		xt.Eq(t, time.Date(2023, 8, 26, 0, 0, 0, 0, time.UTC), *env.Date)
+		xt.Eq(t, nil, env.NakedURL)
+		xt.Eq(t, nil, env.NakedTime)
+		xt.Eq(t, nil, env.NakedTZ)
+		xt.Eq(t, nil, env.EmptyIP)
+		xt.Assert(t, !env.EmptyAddr.IsValid())
+		xt.Eq(t, netip.MustParseAddrPort("127.0.0.1:8080"), env.Default)
+	})
+
+	t.Run("syntax errors", func(t *testing.T) {
+		var cases = map[string]struct {
+			dest   any
+			value  string
+			expErr string
+		}{
+			"url": {
+				dest: &struct {
+					V url.URL `envVar:"STD_x8dk3"`
+				}{},
+				value:  "https://[::1",
+				expErr: "STD_x8dk3: syntax error (not a valid URL)",
+			},
+			"net.IP": {
+				dest: &struct {
+					V net.IP `envVar:"STD_x8dk3"`
+				}{},
+				value:  "192.0.2",
+				expErr: "STD_x8dk3: syntax error (not a valid IP address)",
+			},
+			"net.IPNet": {
+				dest: &struct {
+					V *net.IPNet `envVar:"STD_x8dk3"`
+				}{},
+				value:  "10.0.0.0",
+				expErr: "STD_x8dk3: syntax error (not a valid IP network in CIDR notation)",
+			},
+			"netip.Addr": {
+				dest: &struct {
+					V netip.Addr `envVar:"STD_x8dk3"`
+				}{},
+				value:  "localhost",
+				expErr: "STD_x8dk3: syntax error (not a valid IP address)",
+			},
+			"netip.AddrPort": {
+				dest: &struct {
+					V netip.AddrPort `envVar:"STD_x8dk3"`
+				}{},
+				value:  "127.0.0.1",
+				expErr: "STD_x8dk3: syntax error (not a valid IP address and port)",
+			},
+			"netip.Prefix": {
+				dest: &struct {
+					V netip.Prefix `envVar:"STD_x8dk3"`
+				}{},
+				value:  "10.0.0.0/33",
+				expErr: "STD_x8dk3: syntax error (not a valid IP prefix)",
+			},
+			"time.Location": {
+				dest: &struct {
+					V *time.Location `envVar:"STD_x8dk3"`
+				}{},
+				value:  "Mars/Olympus_Mons",
+				expErr: "STD_x8dk3: syntax error (unknown time zone location)",
+			},
+			"time.Time default layout": {
+				dest: &struct {
+					V time.Time `envVar:"STD_x8dk3"`
+				}{},
+				value:  "2023-08-26",
+				expErr: `STD_x8dk3: syntax error (not parsable as time using layout "2006-01-02T15:04:05Z07:00")`,
+			},
+			"time.Time with layout": {
+				dest: &struct {
+					V time.Time `envVar:"STD_x8dk3" layout:"2006-01-02"`
+				}{},
+				value:  "26/08/2023",
+				expErr: `STD_x8dk3: syntax error (not parsable as time using layout "2006-01-02")`,
+			},
+		}
+
+		for cn, c := range cases {
+			t.Run(cn, func(t *testing.T) {
+				xt.OK(t, os.Setenv("STD_x8dk3", c.value))
+				err := OSEnviron(c.dest)
+				xt.KO(t, err)
+				xt.Eq(t, c.expErr, err.Error())
+			})
+		}
+		xt.OK(t, os.Unsetenv("STD_x8dk3"))
+	})
+}